@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"runtime"
 	"testing"
 	. "wordbuilder/models"
 	"wordbuilder/services"
@@ -32,7 +34,6 @@ func BenchmarkInitialWordBuilderState(b *testing.B) {
 			Step:             0,
 			IsValidWord:      false,
 			ValidCompletions: []string{},
-			Suggestion:       "",
 		}
 		_ = UpdateSets(state, dictionary)
 	}
@@ -53,3 +54,114 @@ func BenchmarkSingleLetterSuffixSearch(b *testing.B) {
 		})
 	}
 }
+
+// Benchmark for building the substring indexes used for embedded letters
+func BenchmarkSubstringIndexBuild(b *testing.B) {
+	dictService := services.NewDictionaryService()
+	wordList, _ := dictService.LoadWordList("words.txt")
+
+	b.ResetTimer() // Ignore setup time
+	for i := 0; i < b.N; i++ {
+		NewSubstringIndex(wordList)
+	}
+}
+
+// Benchmark for UpdateSets on fragments found inside words, which exercises
+// the embedded letter lookup
+func BenchmarkEmbeddedUpdateSets(b *testing.B) {
+	dictService := services.NewDictionaryService()
+	wordList, _ := dictService.LoadWordList("words.txt")
+	dictionary := NewWordDictionary(wordList)
+	dictionary.GetSubstringIndex() // Build the indexes outside the timer
+
+	for _, answer := range []string{"a", "an", "ati", "tion"} {
+		b.Run("Answer_"+answer, func(b *testing.B) {
+			state := WordBuilderState{Answer: answer}
+			for i := 0; i < b.N; i++ {
+				_ = UpdateSets(state, dictionary)
+			}
+		})
+	}
+}
+
+// dictionaryStructures are the ways a dictionary can hold its words
+var dictionaryStructures = []struct {
+	name string
+	opts DictionaryOptions
+}{
+	{"Trie", DictionaryOptions{}},
+	{"DAWG", DictionaryOptions{Compact: true}},
+}
+
+// Benchmark for building a dictionary with each structure, reporting the
+// heap the dictionary keeps once built and played a first move on
+func BenchmarkDictionaryStructureCreation(b *testing.B) {
+	dictService := services.NewDictionaryService()
+	wordList, _ := dictService.LoadWordList("words.txt")
+
+	for _, structure := range dictionaryStructures {
+		b.Run(structure.name, func(b *testing.B) {
+			var retained int64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				dictionary := NewWordDictionaryWithOptions(wordList, structure.opts)
+				// The game builds the substring indexes on its first move
+				_ = UpdateSets(WordBuilderState{Answer: "sta"}, dictionary)
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+				runtime.KeepAlive(dictionary)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "heap-B/dict")
+		})
+	}
+}
+
+// Benchmark for the lookups the game makes on every move, with each structure
+func BenchmarkDictionaryStructureLookups(b *testing.B) {
+	dictService := services.NewDictionaryService()
+	wordList, _ := dictService.LoadWordList("words.txt")
+
+	for _, structure := range dictionaryStructures {
+		dictionary := NewWordDictionaryWithOptions(wordList, structure.opts)
+		b.Run(structure.name+"/ContainsWord", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dictionary.ContainsWord("station")
+			}
+		})
+		b.Run(structure.name+"/FindWordsWithPrefix", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dictionary.FindWordsWithPrefix("sta")
+			}
+		})
+		b.Run(structure.name+"/UpdateSets", func(b *testing.B) {
+			state := WordBuilderState{Answer: "sta"}
+			for i := 0; i < b.N; i++ {
+				_ = UpdateSets(state, dictionary)
+			}
+		})
+	}
+}
+
+// Benchmark for reading a dictionary back from a snapshot, to compare with
+// BenchmarkDictionaryStructureCreation
+func BenchmarkDictionarySnapshotLoad(b *testing.B) {
+	dictService := services.NewDictionaryService()
+	wordList, _ := dictService.LoadWordList("words.txt")
+
+	for _, structure := range dictionaryStructures {
+		var snapshot bytes.Buffer
+		if err := WriteSnapshot(&snapshot, NewWordDictionaryWithOptions(wordList, structure.opts), "bench"); err != nil {
+			b.Fatalf("Failed to write snapshot: %v", err)
+		}
+		b.Run(structure.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadSnapshot(bytes.NewReader(snapshot.Bytes()), "bench"); err != nil {
+					b.Fatalf("Failed to read snapshot: %v", err)
+				}
+			}
+		})
+	}
+}
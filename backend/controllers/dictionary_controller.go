@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"wordbuilder/models"
+	"wordbuilder/services"
 
 	lru "github.com/hashicorp/golang-lru"
 
@@ -59,15 +64,25 @@ type WordDetails struct {
 	ImageUrl      string `json:"imageUrl,omitempty"`
 }
 
+// Bounds on the words the anagram and similar word searches return
+const (
+	defaultAnagramLimit = 100
+	maxAnagramLimit     = 1000
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 100
+)
+
 // DictionaryController handles dictionary-related requests
 type DictionaryController struct {
 	SettingsController *SettingsController
+	WordBuilderService *services.WordBuilderService // Resolves word lists to dictionaries
 }
 
 // NewDictionaryController creates a new dictionary controller
-func NewDictionaryController(settingsController *SettingsController) *DictionaryController {
+func NewDictionaryController(settingsController *SettingsController, wbService *services.WordBuilderService) *DictionaryController {
 	return &DictionaryController{
 		SettingsController: settingsController,
+		WordBuilderService: wbService,
 	}
 }
 
@@ -256,6 +271,122 @@ func (c *DictionaryController) GetCompleteWordDetails(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, details)
 }
 
+// GetAnagrams lists the words of a word list spelled with some of the given
+// letters, where '?' stands for any letter. Words using every letter are
+// also listed on their own as the anagrams.
+func (c *DictionaryController) GetAnagrams(ctx *gin.Context) {
+	wordListID := 0
+	if id := ctx.Query("word_list_id"); id != "" {
+		var err error
+		if wordListID, err = strconv.Atoi(id); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word list ID"})
+			return
+		}
+	}
+	minLength, err := strconv.Atoi(ctx.DefaultQuery("min_length", "2"))
+	if err != nil || minLength < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid minimum length"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultAnagramLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultAnagramLimit
+	}
+	if limit > maxAnagramLimit {
+		limit = maxAnagramLimit
+	}
+
+	dictionary, wordListID, err := c.WordBuilderService.Dictionary(wordListID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+		return
+	}
+	letters, blanks, err := models.ParseAnagramLetters(dictionary, ctx.Query("letters"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	matches := dictionary.SubAnagrams(letters, blanks, minLength)
+	anagrams := []string{}
+	if blanks == 0 {
+		anagrams = dictionary.Anagrams(string(letters))
+	} else {
+		for _, match := range matches {
+			if match.Exact {
+				anagrams = append(anagrams, match.Word)
+			}
+		}
+	}
+
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if matches == nil {
+		matches = []models.AnagramMatch{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"word_list_id": wordListID,
+		"anagrams":     anagrams,
+		"words":        matches,
+		"total":        total,
+	})
+}
+
+// GetSimilarWords suggests words of a word list close to a possibly
+// misspelled word, for "did you mean" prompts
+func (c *DictionaryController) GetSimilarWords(ctx *gin.Context) {
+	word := ctx.Query("word")
+	if word == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Word parameter is required"})
+		return
+	}
+	wordListID := 0
+	if id := ctx.Query("word_list_id"); id != "" {
+		var err error
+		if wordListID, err = strconv.Atoi(id); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word list ID"})
+			return
+		}
+	}
+	maxDistance, err := strconv.Atoi(ctx.DefaultQuery("max_distance", "2"))
+	if err != nil || maxDistance < 1 || maxDistance > models.MaxEditDistance {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_distance must be between 1 and %d", models.MaxEditDistance)})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultSimilarLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultSimilarLimit
+	}
+	if limit > maxSimilarLimit {
+		limit = maxSimilarLimit
+	}
+
+	dictionary, wordListID, err := c.WordBuilderService.Dictionary(wordListID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+		return
+	}
+
+	similar, err := dictionary.FindSimilarWords(word, maxDistance, limit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if similar == nil {
+		similar = []models.SimilarWord{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"word_list_id": wordListID,
+		"word":         word,
+		"is_word":      dictionary.ContainsWord(strings.ToLower(word)),
+		"suggestions":  similar,
+	})
+}
+
 // RegisterRoutes registers all controller routes
 func (c *DictionaryController) RegisterRoutes(router *gin.Engine) {
 	api := router.Group("/api/dictionary")
@@ -263,5 +394,7 @@ func (c *DictionaryController) RegisterRoutes(router *gin.Engine) {
 		api.GET("/image/:word", c.GetWordImage)
 		api.GET("/details/:word", c.GetWordDetails)
 		api.GET("/complete/:word", c.GetCompleteWordDetails)
+		api.GET("/anagrams", c.GetAnagrams)
+		api.GET("/similar", c.GetSimilarWords)
 	}
 }
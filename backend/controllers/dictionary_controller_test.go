@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"wordbuilder/models"
+)
+
+func TestGetAnagrams(t *testing.T) {
+	router, ids, wordBuilderService := newTestRouter(t)
+	NewDictionaryController(nil, wordBuilderService).RegisterRoutes(router)
+
+	w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/dictionary/anagrams?word_list_id=%d&letters=tac", ids[0]), nil)
+	var resp struct {
+		Anagrams []string              `json:"anagrams"`
+		Words    []models.AnagramMatch `json:"words"`
+		Total    int                   `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("anagrams returned %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Anagrams) != 2 || resp.Anagrams[0] != "act" || resp.Anagrams[1] != "cat" {
+		t.Errorf("Expected act and cat, got %v", resp.Anagrams)
+	}
+
+	// The blank makes "cart" and "scat" as well as the three-letter words
+	w = doJSON(router, http.MethodGet, "/api/dictionary/anagrams?letters=tac?&limit=2", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Total != 5 || len(resp.Words) != 2 || resp.Words[0].Word != "cart" || len(resp.Words[0].Blanks) != 1 {
+		t.Errorf("Expected cart first of 5 words, got %d: %+v", resp.Total, resp.Words)
+	}
+	if len(resp.Anagrams) != 2 {
+		t.Errorf("Expected cart and scat to use every letter, got %v", resp.Anagrams)
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/dictionary/anagrams?letters=dog", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("letters outside the alphabet returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/dictionary/anagrams?letters=cat&word_list_id=999", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown word list returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetSimilarWords(t *testing.T) {
+	router, ids, wordBuilderService := newTestRouter(t)
+	NewDictionaryController(nil, wordBuilderService).RegisterRoutes(router)
+
+	w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/dictionary/similar?word_list_id=%d&word=dgo&max_distance=1", ids[1]), nil)
+	var resp struct {
+		IsWord      bool                 `json:"is_word"`
+		Suggestions []models.SimilarWord `json:"suggestions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("similar returned %d: %s", w.Code, w.Body.String())
+	}
+	if resp.IsWord || len(resp.Suggestions) != 0 {
+		t.Errorf("Expected no word within one edit of 'dgo', got %+v", resp)
+	}
+
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/dictionary/similar?word_list_id=%d&word=dgo", ids[1]), nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Suggestions) != 4 || resp.Suggestions[0].Distance != 2 {
+		t.Errorf("Expected ado, dog, dot and god two edits away, got %+v", resp.Suggestions)
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/dictionary/similar?word=cat&max_distance=9", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("too large a distance returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/dictionary/similar?word="+strings.Repeat("a", models.MaxSimilarLetters+1), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("too long a word returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
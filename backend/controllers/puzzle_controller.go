@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"wordbuilder/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PuzzleController handles the puzzle of the day
+type PuzzleController struct {
+	WordBuilderService *services.WordBuilderService
+	DBService          *services.DatabaseService
+}
+
+// NewPuzzleController creates a new controller instance
+func NewPuzzleController(wbService *services.WordBuilderService, dbService *services.DatabaseService) *PuzzleController {
+	return &PuzzleController{
+		WordBuilderService: wbService,
+		DBService:          dbService,
+	}
+}
+
+// GetDailyPuzzle returns the puzzle of the day for a word list without its
+// target words, how many players took it on and, given a session ID, how
+// that session did
+func (c *PuzzleController) GetDailyPuzzle(ctx *gin.Context) {
+	wordListID := 0
+	if id := ctx.Query("word_list_id"); id != "" {
+		var err error
+		if wordListID, err = strconv.Atoi(id); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word list ID"})
+			return
+		}
+	}
+	date := ctx.DefaultQuery("date", services.Today())
+
+	puzzle, err := c.WordBuilderService.DailyPuzzle(date, wordListID)
+	if errors.Is(err, services.ErrWordListNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	players, completed, err := c.DBService.GetDailyStats(puzzle.Date, puzzle.WordListID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load daily stats"})
+		return
+	}
+
+	response := gin.H{
+		"success": true,
+		"puzzle": gin.H{
+			"date":         puzzle.Date,
+			"word_list_id": puzzle.WordListID,
+			"seed":         puzzle.Seed,
+			"target_count": len(puzzle.Targets),
+		},
+		"stats": gin.H{
+			"players":   players,
+			"completed": completed,
+		},
+	}
+
+	if sessionID := ctx.Query("session_id"); sessionID != "" {
+		result, err := c.DBService.GetDailyResult(sessionID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load daily result"})
+			return
+		}
+		// Results for another day or list don't belong to this puzzle
+		if result != nil && result.Date == puzzle.Date && result.WordListID == puzzle.WordListID {
+			response["result"] = result
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RegisterRoutes registers all routes for this controller
+func (c *PuzzleController) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/puzzles")
+	{
+		api.GET("/daily", c.GetDailyPuzzle)
+	}
+}
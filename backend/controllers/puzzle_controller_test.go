@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"wordbuilder/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDailyPuzzle(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+	NewPuzzleController(wordBuilderService, wordBuilderService.DBService).RegisterRoutes(router)
+
+	// Every word starts with "ca", the only fragment all of them can be built from
+	wordList, err := wordBuilderService.WordListService.CreateWordList([]byte("card\ncare\ncars\ncart\n"), "daily", "", "test", models.NormalizeOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create word list: %v", err)
+	}
+	path := fmt.Sprintf("/api/puzzles/daily?word_list_id=%d&date=2024-03-01", wordList.ID)
+
+	type dailyResponse struct {
+		Puzzle struct {
+			Seed        string `json:"seed"`
+			TargetCount int    `json:"target_count"`
+		} `json:"puzzle"`
+		Stats struct {
+			Players   int `json:"players"`
+			Completed int `json:"completed"`
+		} `json:"stats"`
+		Result *struct {
+			Found []string `json:"found"`
+		} `json:"result"`
+	}
+	var daily dailyResponse
+	w := doJSON(router, http.MethodGet, path, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &daily); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("daily returned %d: %s", w.Code, w.Body.String())
+	}
+	if daily.Puzzle.Seed != "ca" || daily.Puzzle.TargetCount != 4 || daily.Stats.Players != 0 {
+		t.Fatalf("Unexpected daily puzzle %+v", daily)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/puzzles/daily?date=someday", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("daily with an invalid date returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	future := fmt.Sprintf("/api/puzzles/daily?word_list_id=%d&date=9999-12-31", wordList.ID)
+	if w := doJSON(router, http.MethodGet, future, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("daily for a day to come returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/puzzles/daily?word_list_id=9999", nil); w.Code != http.StatusNotFound {
+		t.Errorf("daily for a missing word list returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "daily", "word_list_id": wordList.ID, "date": "9999-12-31"}); w.Code != http.StatusBadRequest {
+		t.Errorf("init for a day to come returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var session struct {
+		SessionID string `json:"session_id"`
+		State     struct {
+			Answer string `json:"answer"`
+		} `json:"state"`
+	}
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "daily", "word_list_id": wordList.ID, "date": "2024-03-01"})
+	if err := json.Unmarshal(w.Body.Bytes(), &session); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("init returned %d: %s", w.Code, w.Body.String())
+	}
+	if session.State.Answer != "ca" {
+		t.Fatalf("Expected to start from the seed, got %q", session.State.Answer)
+	}
+	for _, letter := range []string{"r", "t"} {
+		body := gin.H{"session_id": session.SessionID, "letter": letter, "position": "suffix"}
+		if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", body); w.Code != http.StatusOK {
+			t.Fatalf("add returned %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	daily = dailyResponse{}
+	w = doJSON(router, http.MethodGet, path+"&session_id="+session.SessionID, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &daily); err != nil {
+		t.Fatalf("Failed to decode daily response: %v", err)
+	}
+	if daily.Stats.Players != 1 || daily.Stats.Completed != 0 {
+		t.Errorf("Expected one player who hasn't finished, got %+v", daily.Stats)
+	}
+	if daily.Result == nil || len(daily.Result.Found) != 1 || daily.Result.Found[0] != "cart" {
+		t.Errorf("Expected the session to have found 'cart', got %+v", daily.Result)
+	}
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	models "wordbuilder/models"
+	"wordbuilder/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// RoomController handles multiplayer rooms
+type RoomController struct {
+	RoomService *services.RoomService
+}
+
+// NewRoomController creates a new controller instance
+func NewRoomController(roomService *services.RoomService) *RoomController {
+	return &RoomController{
+		RoomService: roomService,
+	}
+}
+
+// roomMessage is sent by a player over the room's WebSocket
+type roomMessage struct {
+	Type     string `json:"type"` // "start" or "add"
+	Letter   string `json:"letter"`
+	Position string `json:"position"`
+}
+
+// CreateRoom opens a new room
+func (c *RoomController) CreateRoom(ctx *gin.Context) {
+	// The body is optional, without a word list the default dictionary is used
+	var req struct {
+		WordListID int `json:"word_list_id"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	roomID, room, err := c.RoomService.CreateRoom(req.WordListID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"room_id": roomID,
+		"room":    models.GetRoomView(room),
+	})
+}
+
+// GetRoom returns the current state of a room
+func (c *RoomController) GetRoom(ctx *gin.Context) {
+	room, exists := c.RoomService.GetRoom(ctx.Param("id"))
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"room":    models.GetRoomView(room),
+	})
+}
+
+// Connect upgrades to a WebSocket that carries the player's moves and every
+// change to the room. Players reconnect by passing the session_id they were
+// given when they first joined.
+func (c *RoomController) Connect(ctx *gin.Context) {
+	roomID := ctx.Param("id")
+	if _, exists := c.RoomService.GetRoom(roomID); !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	sessionID := ctx.Query("session_id")
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+	name := ctx.Query("name")
+
+	// The handshake is left out so browsers from any origin can connect,
+	// matching the CORS policy of the REST API
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		c.play(ws, roomID, sessionID, name)
+	}}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// play runs a player's connection until either side closes it
+func (c *RoomController) play(ws *websocket.Conn, roomID, sessionID, name string) {
+	defer ws.Close()
+
+	events, seat, err := c.RoomService.Join(roomID, sessionID, name)
+	if err != nil {
+		websocket.JSON.Send(ws, gin.H{"type": "error", "error": err.Error()})
+		return
+	}
+	defer c.RoomService.Leave(roomID, sessionID, events)
+
+	if err := websocket.JSON.Send(ws, gin.H{"type": "joined", "session_id": sessionID, "seat": seat}); err != nil {
+		return
+	}
+
+	// Events are written from their own goroutine so a slow reader never
+	// holds up the room; closing the connection ends the read loop below
+	go func() {
+		for event := range events {
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				break
+			}
+		}
+		ws.Close()
+	}()
+
+	for {
+		var msg roomMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		var message string
+		switch msg.Type {
+		case "start":
+			message, err = c.RoomService.Start(roomID, sessionID)
+		case "add":
+			message, err = c.RoomService.Move(roomID, sessionID, msg.Letter, msg.Position)
+		default:
+			message, err = fmt.Sprintf("Unknown message type '%s'.", msg.Type), fmt.Errorf("unknown message type")
+		}
+		if err != nil {
+			websocket.JSON.Send(ws, gin.H{"type": "error", "error": err.Error(), "message": message})
+		}
+	}
+}
+
+// RegisterRoutes registers all routes for this controller
+func (c *RoomController) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/rooms")
+	{
+		api.POST("", c.CreateRoom)
+		api.GET("/:id", c.GetRoom)
+		api.GET("/:id/ws", c.Connect)
+	}
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"wordbuilder/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// roomEvent is the part of a room event the tests look at
+type roomEvent struct {
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+	Seat      int    `json:"seat"`
+	Error     string `json:"error"`
+	Room      struct {
+		Started bool `json:"started"`
+		Turn    int  `json:"turn"`
+		Players []struct {
+			Name      string `json:"name"`
+			Connected bool   `json:"connected"`
+		} `json:"players"`
+		State struct {
+			Answer string `json:"answer"`
+		} `json:"state"`
+	} `json:"room"`
+}
+
+func newRoomServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	router, _, wordBuilderService := newTestRouter(t)
+	NewRoomController(services.NewRoomService(wordBuilderService)).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	w := doJSON(router, http.MethodPost, "/api/rooms", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create room returned %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode create response: %v", err)
+	}
+	return server, resp.RoomID
+}
+
+func dialRoom(t *testing.T, server *httptest.Server, roomID, query string) *websocket.Conn {
+	t.Helper()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/rooms/" + roomID + "/ws?" + query
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// waitFor reads events until one matches
+func waitFor(t *testing.T, ws *websocket.Conn, match func(roomEvent) bool) roomEvent {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var event roomEvent
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("Failed to receive event: %v", err)
+		}
+		if match(event) {
+			return event
+		}
+	}
+}
+
+func TestRoomOverWebSocket(t *testing.T) {
+	server, roomID := newRoomServer(t)
+
+	ann := dialRoom(t, server, roomID, "name=ann")
+	joined := waitFor(t, ann, func(e roomEvent) bool { return e.Type == "joined" })
+	if joined.Seat != 0 || joined.SessionID == "" {
+		t.Fatalf("Expected ann in seat 0 with a session ID, got %+v", joined)
+	}
+
+	bob := dialRoom(t, server, roomID, "name=bob")
+	bobSession := waitFor(t, bob, func(e roomEvent) bool { return e.Type == "joined" }).SessionID
+	waitFor(t, ann, func(e roomEvent) bool { return len(e.Room.Players) == 2 })
+
+	websocket.JSON.Send(ann, gin.H{"type": "start"})
+	waitFor(t, bob, func(e roomEvent) bool { return e.Room.Started })
+
+	// Moving out of turn is only reported to the player who tried
+	websocket.JSON.Send(bob, gin.H{"type": "add", "letter": "c", "position": "prefix"})
+	if event := waitFor(t, bob, func(e roomEvent) bool { return e.Type == "error" }); event.Error == "" {
+		t.Error("Expected an error moving out of turn")
+	}
+
+	websocket.JSON.Send(ann, gin.H{"type": "add", "letter": "c", "position": "prefix"})
+	event := waitFor(t, bob, func(e roomEvent) bool { return e.Room.State.Answer == "c" })
+	if event.Room.Turn != 1 {
+		t.Errorf("Expected bob to move next, got turn %d", event.Room.Turn)
+	}
+
+	// Dropping the connection keeps the seat for a reconnect
+	bob.Close()
+	waitFor(t, ann, func(e roomEvent) bool { return len(e.Room.Players) == 2 && !e.Room.Players[1].Connected })
+
+	bob = dialRoom(t, server, roomID, "session_id="+url.QueryEscape(bobSession))
+	if joined := waitFor(t, bob, func(e roomEvent) bool { return e.Type == "joined" }); joined.Seat != 1 {
+		t.Errorf("Expected bob back in seat 1, got %d", joined.Seat)
+	}
+	event = waitFor(t, bob, func(e roomEvent) bool { return e.Type == "state" })
+	if !event.Room.Players[1].Connected || event.Room.Players[1].Name != "bob" || event.Room.State.Answer != "c" {
+		t.Errorf("Expected bob reconnected to the same game, got %+v", event.Room)
+	}
+}
+
+func TestRoomNotFound(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+	NewRoomController(services.NewRoomService(wordBuilderService)).RegisterRoutes(router)
+
+	if w := doJSON(router, http.MethodGet, "/api/rooms/missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get unknown room returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/rooms/missing/ws", nil); w.Code != http.StatusNotFound {
+		t.Errorf("connect to unknown room returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRoomDropsSlowPlayers(t *testing.T) {
+	_, _, wordBuilderService := newTestRouter(t)
+	roomService := services.NewRoomService(wordBuilderService)
+	roomID, _, err := roomService.CreateRoom(0)
+	if err != nil {
+		t.Fatalf("Failed to create room: %v", err)
+	}
+
+	// ann never reads her events, while bob keeps up
+	ann, _, _ := roomService.Join(roomID, "ann", "ann")
+	bob, _, _ := roomService.Join(roomID, "bob", "bob")
+	var lost *services.RoomEvent
+	drain := func() {
+		for {
+			select {
+			case event := <-bob:
+				if strings.Contains(event.Message, "lost the connection") {
+					lost = &event
+				}
+			default:
+				return
+			}
+		}
+	}
+	for i := 0; i < 20; i++ {
+		events, _, err := roomService.Join(roomID, "cy", "cy")
+		if err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+		roomService.Leave(roomID, "cy", events)
+		drain()
+	}
+
+	// ann's channel is closed once the buffered events are read
+	for range ann {
+	}
+	if lost == nil || lost.Message != "ann lost the connection." {
+		t.Fatalf("Expected bob to be told ann lost the connection, got %+v", lost)
+	}
+	for _, player := range lost.Room["players"].([]map[string]interface{}) {
+		if player["name"] == "ann" {
+			t.Errorf("Expected ann's seat to be given up, got %+v", lost.Room["players"])
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 	models "wordbuilder/models"
 	"wordbuilder/services"
 
@@ -10,6 +16,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Bounds on the words the rack solver returns
+const (
+	defaultSolveLimit = 20
+	maxSolveLimit     = 100
+)
+
 // WordBuilderController handles HTTP requests for wordbuilder game
 type WordBuilderController struct {
 	WordBuilderService *services.WordBuilderService
@@ -24,77 +36,101 @@ func NewWordBuilderController(wbService *services.WordBuilderService) *WordBuild
 
 // InitSession initializes a new WordBuilder session
 func (c *WordBuilderController) InitSession(ctx *gin.Context) {
-	sessionID := uuid.New().String()
-	dictService := services.NewDictionaryService() // Create it here
-	builder := c.WordBuilderService.CreateSession(sessionID, dictService)
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"session_id": sessionID,
-		"state":      models.GetCurrentState(*builder), // builder IS the state!
-		"success":    true,
-	})
-}
-
-// ResetSession resets a WordBuilder session
-func (c *WordBuilderController) ResetSession(ctx *gin.Context) {
+	// The body is optional, without a word list the default dictionary is used
 	var req struct {
-		SessionID string `json:"session_id"`
+		WordListID      int            `json:"word_list_id"`
+		Mode            string         `json:"mode"`
+		Difficulty      string         `json:"difficulty"`
+		DurationSeconds int            `json:"duration_seconds"` // Starts a timed round
+		MinLength       int            `json:"min_length"`       // Target lengths in puzzle mode
+		MaxLength       int            `json:"max_length"`
+		Date            string         `json:"date"`         // Day of a daily puzzle, today when empty
+		Rack            string         `json:"rack"`         // Tiles given by the teacher in rack mode
+		RackSize        int            `json:"rack_size"`    // Tiles to draw in rack mode
+		Distribution    map[string]int `json:"distribution"` // Tiles per letter to draw from
 	}
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	builder, exists := c.WordBuilderService.ResetSession(req.SessionID)
-	if !exists {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	sessionID := uuid.New().String()
+	dictService := services.NewDictionaryService() // Create it here
+	builder, err := c.WordBuilderService.CreateSession(sessionID, services.SessionOptions{
+		WordListID: req.WordListID,
+		Mode:       req.Mode,
+		Difficulty: req.Difficulty,
+		Duration:   time.Duration(req.DurationSeconds) * time.Second,
+		MinLength:  req.MinLength,
+		MaxLength:  req.MaxLength,
+		Date:       req.Date,
+
+		Rack:         req.Rack,
+		RackSize:     req.RackSize,
+		Distribution: req.Distribution,
+	}, dictService)
+	if errors.Is(err, services.ErrInvalidSessionOptions) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"state":   models.GetCurrentState(*builder),
-		"message": "Word builder has been reset.",
+		"session_id": sessionID,
+		"state":      models.GetCurrentState(builder), // builder IS the state!
+		"success":    true,
 	})
 }
 
-// models.AddLetter adds a letter to the word
+// ResetSession resets a WordBuilder session
+func (c *WordBuilderController) ResetSession(ctx *gin.Context) {
+	c.sessionAction(ctx, c.WordBuilderService.ResetSession)
+}
+
+// AddLetter adds a letter at either end of the word or inside it
 func (c *WordBuilderController) AddLetter(ctx *gin.Context) {
 	var req struct {
 		SessionID string `json:"session_id"`
 		Letter    string `json:"letter"`
 		Position  string `json:"position"`
+		Index     *int   `json:"index"` // Where to insert the letter, for the "insert" position
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	state, exists := c.WordBuilderService.GetSession(req.SessionID)
-	if !exists {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	// Whether the letter belongs to the word list is up to the session's dictionary
+	if utf8.RuneCountInString(req.Letter) != 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Letter must be a single letter"})
 		return
 	}
-
-	if len(req.Letter) != 1 || !strings.Contains("abcdefghijklmnopqrstuvwxyz", strings.ToLower(req.Letter)) {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Letter must be a single lowercase letter"})
+	if req.Position != "prefix" && req.Position != "suffix" && req.Position != "insert" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Position must be 'prefix', 'suffix' or 'insert'"})
 		return
 	}
-	if req.Position != "prefix" && req.Position != "suffix" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Position must be 'prefix' or 'suffix'"})
+	if req.Position == "insert" && req.Index == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Index is required to insert a letter"})
 		return
 	}
 
-	// Use the main dictionary from the service, not from the state
-	newState, message, err := models.AddLetter(*state, c.WordBuilderService.Dictionary, strings.ToLower(req.Letter), req.Position)
+	// The service applies the move under the session's lock
+	var newState models.WordBuilderState
+	var message string
+	var err error
+	if req.Position == "insert" {
+		newState, message, err = c.WordBuilderService.InsertLetter(req.SessionID, strings.ToLower(req.Letter), *req.Index)
+	} else {
+		newState, message, err = c.WordBuilderService.AddLetter(req.SessionID, strings.ToLower(req.Letter), req.Position)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondMoveError(ctx, newState, message, err)
 		return
 	}
 
-	// Update the session with the new state
-	c.WordBuilderService.Sessions[req.SessionID] = &newState
-
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"state":   models.GetCurrentState(newState),
@@ -113,20 +149,54 @@ func (c *WordBuilderController) RemoveLetter(ctx *gin.Context) {
 		return
 	}
 
-	state, exists := c.WordBuilderService.GetSession(req.SessionID)
-	if !exists {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	newState, message, err := c.WordBuilderService.RemoveLetter(req.SessionID, req.Index)
+	if err != nil {
+		respondMoveError(ctx, newState, message, err)
 		return
 	}
 
-	newState, message, err := models.RemoveLetter(*state, c.WordBuilderService.Dictionary, req.Index)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"state":   models.GetCurrentState(newState),
+		"message": message,
+	})
+}
+
+// UndoMove reverts the last move of a session
+func (c *WordBuilderController) UndoMove(ctx *gin.Context) {
+	c.sessionAction(ctx, c.WordBuilderService.UndoMove)
+}
+
+// RedoMove reapplies the last undone move of a session
+func (c *WordBuilderController) RedoMove(ctx *gin.Context) {
+	c.sessionAction(ctx, c.WordBuilderService.RedoMove)
+}
+
+// Challenge claims that no word contains the fragment in a ghost game
+func (c *WordBuilderController) Challenge(ctx *gin.Context) {
+	c.sessionAction(ctx, c.WordBuilderService.Challenge)
+}
+
+// Hint gives the next hint for the answer of a session
+func (c *WordBuilderController) Hint(ctx *gin.Context) {
+	c.sessionAction(ctx, c.WordBuilderService.Hint)
+}
+
+// sessionAction handles requests that only carry a session ID
+func (c *WordBuilderController) sessionAction(ctx *gin.Context, action func(sessionID string) (models.WordBuilderState, string, error)) {
+	var req struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	// Update the session with the new state
-	c.WordBuilderService.Sessions[req.SessionID] = &newState
+	newState, message, err := action(req.SessionID)
+	if err != nil {
+		respondMoveError(ctx, newState, message, err)
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -138,27 +208,94 @@ func (c *WordBuilderController) RemoveLetter(ctx *gin.Context) {
 // GetState returns the current state of a session
 func (c *WordBuilderController) GetState(ctx *gin.Context) {
 	sessionID := ctx.Query("session_id")
-	builder, exists := c.WordBuilderService.GetSession(sessionID)
+	state, exists := c.WordBuilderService.GetSession(sessionID)
 	if !exists {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
 		return
 	}
 
-	state := models.WordBuilderState{
-		Answer:           builder.Answer,
-		PrefixSet:        builder.PrefixSet,
-		SuffixSet:        builder.SuffixSet,
-		Step:             builder.Step,
-		IsValidWord:      builder.IsValidWord,
-		ValidCompletions: builder.ValidCompletions,
-		Suggestion:       builder.Suggestion,
+	ctx.JSON(http.StatusOK, gin.H{
+		"state": models.GetCurrentState(state),
+	})
+}
+
+// SolveRack lists the best words that can be built from a rack, either the
+// rack of a session or letters given with a word list
+func (c *WordBuilderController) SolveRack(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultSolveLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultSolveLimit
+	}
+	if limit > maxSolveLimit {
+		limit = maxSolveLimit
 	}
 
+	var words []models.WordScore
+	if sessionID := ctx.Query("session_id"); sessionID != "" {
+		words, err = c.WordBuilderService.SolveSessionRack(sessionID, limit)
+	} else {
+		wordListID := 0
+		if id := ctx.Query("word_list_id"); id != "" {
+			if wordListID, err = strconv.Atoi(id); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word list ID"})
+				return
+			}
+		}
+		words, err = c.WordBuilderService.SolveRack(wordListID, ctx.Query("letters"), limit)
+	}
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	case errors.Is(err, services.ErrInvalidRack):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+		return
+	}
+
+	if words == nil {
+		words = []models.WordScore{}
+	}
 	ctx.JSON(http.StatusOK, gin.H{
-		"state": models.GetCurrentState(state),
+		"success": true,
+		"words":   words,
+	})
+}
+
+// DeleteSession ends a session so its state is discarded
+func (c *WordBuilderController) DeleteSession(ctx *gin.Context) {
+	sessionID := ctx.Query("session_id")
+	if !c.WordBuilderService.DeleteSession(sessionID) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session ended.",
 	})
 }
 
+// respondMoveError writes the response for a move the service rejected
+func respondMoveError(ctx *gin.Context, state models.WordBuilderState, message string, err error) {
+	if errors.Is(err, services.ErrSessionNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	// The final state carries the words found during the round
+	if errors.Is(err, services.ErrTimeUp) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":   err.Error(),
+			"state":   models.GetCurrentState(state),
+			"message": message,
+		})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 // RegisterRoutes registers all controller routes
 func (c *WordBuilderController) RegisterRoutes(router *gin.Engine) {
 	api := router.Group("/api/wordbuilder")
@@ -167,6 +304,12 @@ func (c *WordBuilderController) RegisterRoutes(router *gin.Engine) {
 		api.POST("/reset", c.ResetSession)
 		api.POST("/add", c.AddLetter)
 		api.POST("/remove", c.RemoveLetter)
+		api.POST("/undo", c.UndoMove)
+		api.POST("/redo", c.RedoMove)
+		api.POST("/challenge", c.Challenge)
+		api.POST("/hint", c.Hint)
 		api.GET("/state", c.GetState)
+		api.GET("/rack/solve", c.SolveRack)
+		api.DELETE("/session", c.DeleteSession)
 	}
 }
@@ -0,0 +1,684 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wordbuilder/models"
+	"wordbuilder/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter wires the word builder and word list controllers against a
+// temporary database with two uploaded word lists
+func newTestRouter(t *testing.T) (*gin.Engine, []int, *services.WordBuilderService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dataDir := t.TempDir()
+	dbService, err := services.NewDatabaseService(filepath.Join(dataDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { dbService.Close() })
+
+	dictService := services.NewDictionaryService()
+	wordListService := services.NewWordListService(dbService, dictService, filepath.Join(dataDir, "uploads"))
+
+	var ids []int
+	for i, words := range []string{"cat\ncar\ncart\nscat\nact\n", "dog\ndot\ndote\nado\ngod\n"} {
+		wordList, err := wordListService.CreateWordList([]byte(words), fmt.Sprintf("list %d", i), "", "test", models.NormalizeOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create word list: %v", err)
+		}
+		ids = append(ids, wordList.ID)
+	}
+
+	dictionary, err := wordListService.LoadWordListIntoDictionary(ids[0])
+	if err != nil {
+		t.Fatalf("Failed to load dictionary: %v", err)
+	}
+	wordBuilderService := services.NewWordBuilderService(dictionary, ids[0], dbService, wordListService)
+
+	router := gin.New()
+	NewWordBuilderController(wordBuilderService).RegisterRoutes(router)
+	NewWordListController(wordListService, wordBuilderService).RegisterRoutes(router)
+	return router, ids, wordBuilderService
+}
+
+func doJSON(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func initSession(t *testing.T, router *gin.Engine, wordListID int) string {
+	t.Helper()
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"word_list_id": wordListID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("init returned %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode init response: %v", err)
+	}
+	return resp.SessionID
+}
+
+// TestConcurrentMovesWhileSwappingDictionaries is meant to be run with -race
+func TestConcurrentMovesWhileSwappingDictionaries(t *testing.T) {
+	router, ids, _ := newTestRouter(t)
+
+	sessionIDs := make([]string, 8)
+	for i := range sessionIDs {
+		sessionIDs[i] = initSession(t, router, 0)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 1000)
+
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			sessionID := sessionIDs[worker%len(sessionIDs)]
+			for j := 0; j < 25; j++ {
+				letter := string("catdoger"[(worker+j)%8])
+				position := "suffix"
+				if j%2 == 0 {
+					position = "prefix"
+				}
+				w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{
+					"session_id": sessionID,
+					"letter":     letter,
+					"position":   position,
+				})
+				// Invalid letters are rejected with 400, anything else is a bug
+				if w.Code != http.StatusOK && w.Code != http.StatusBadRequest {
+					errs <- fmt.Sprintf("add returned %d: %s", w.Code, w.Body.String())
+				}
+				if j%5 == 4 {
+					doJSON(router, http.MethodPost, "/api/wordbuilder/remove", gin.H{"session_id": sessionID, "index": 0})
+				}
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			w := doJSON(router, http.MethodPost, fmt.Sprintf("/api/wordlists/%d/use", ids[j%len(ids)]), nil)
+			if w.Code != http.StatusOK {
+				errs <- fmt.Sprintf("use returned %d: %s", w.Code, w.Body.String())
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, sessionID := range sessionIDs {
+		w := doJSON(router, http.MethodGet, "/api/wordbuilder/state?session_id="+sessionID, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("state for %s returned %d", sessionID, w.Code)
+		}
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+	sessionID := initSession(t, router, 0)
+
+	w := doJSON(router, http.MethodDelete, "/api/wordbuilder/session?session_id="+sessionID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete returned %d: %s", w.Code, w.Body.String())
+	}
+
+	// The session is gone from memory and from the database
+	w = doJSON(router, http.MethodGet, "/api/wordbuilder/state?session_id="+sessionID, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("state after delete returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+	w = doJSON(router, http.MethodDelete, "/api/wordbuilder/session?session_id="+sessionID, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second delete returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestReapIdleSessions(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+	wordBuilderService.SessionTTL = 50 * time.Millisecond
+
+	idle := initSession(t, router, 0)
+	active := initSession(t, router, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	// A move keeps the session alive
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": active, "letter": "c", "position": "prefix"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("add returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if reaped := wordBuilderService.ReapIdleSessions(time.Now()); reaped != 1 {
+		t.Errorf("Expected 1 session reaped, got %d", reaped)
+	}
+	if _, exists := wordBuilderService.GetSession(idle); exists {
+		t.Error("Idle session should have been reaped")
+	}
+	if _, exists := wordBuilderService.GetSession(active); !exists {
+		t.Error("Active session should have been kept")
+	}
+}
+
+func TestMaxSessionsEvictsLeastActive(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+	wordBuilderService.MaxSessions = 2
+
+	first := initSession(t, router, 0)
+	initSession(t, router, 0)
+	initSession(t, router, 0)
+
+	// The evicted session is still persisted, so it is loaded back on demand
+	w := doJSON(router, http.MethodGet, "/api/wordbuilder/state?session_id="+first, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("state for evicted session returned %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMaxSessionsNeedsDatabase(t *testing.T) {
+	wordBuilderService := services.NewWordBuilderService(models.NewWordDictionary([]string{"cat", "car"}), 0, nil, nil)
+	wordBuilderService.MaxSessions = 1
+
+	for _, sessionID := range []string{"first", "second"} {
+		if _, err := wordBuilderService.CreateSession(sessionID, services.SessionOptions{}, nil); err != nil {
+			t.Fatalf("Failed to create %s: %v", sessionID, err)
+		}
+	}
+	// Without a database an evicted session couldn't be loaded back
+	if _, exists := wordBuilderService.GetSession("first"); !exists {
+		t.Error("Expected the first session to be kept without a database")
+	}
+}
+
+func TestSessionsKeepTheirWordList(t *testing.T) {
+	router, ids, _ := newTestRouter(t)
+
+	cats := initSession(t, router, ids[0])
+	dogs := initSession(t, router, ids[1])
+
+	add := func(sessionID, letter, position string) int {
+		return doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": letter, "position": position}).Code
+	}
+	if code := add(cats, "c", "prefix"); code != http.StatusOK {
+		t.Errorf("Adding 'c' to the first list returned %d", code)
+	}
+	if code := add(dogs, "d", "prefix"); code != http.StatusOK {
+		t.Errorf("Adding 'd' to the second list returned %d", code)
+	}
+
+	// Switching the default list must not reset or rebind existing sessions
+	if w := doJSON(router, http.MethodPost, fmt.Sprintf("/api/wordlists/%d/use", ids[1]), nil); w.Code != http.StatusOK {
+		t.Fatalf("use returned %d: %s", w.Code, w.Body.String())
+	}
+	if code := add(cats, "a", "suffix"); code != http.StatusOK {
+		t.Errorf("Adding 'a' after switching lists returned %d", code)
+	}
+
+	var resp struct {
+		State struct {
+			Answer     string `json:"answer"`
+			WordListID int    `json:"word_list_id"`
+		} `json:"state"`
+	}
+	w := doJSON(router, http.MethodGet, "/api/wordbuilder/state?session_id="+cats, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if resp.State.Answer != "ca" || resp.State.WordListID != ids[0] {
+		t.Errorf("Expected answer 'ca' on list %d, got %q on list %d", ids[0], resp.State.Answer, resp.State.WordListID)
+	}
+
+	// New sessions without a word list pick up the new default
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode init response: %v", err)
+	}
+	if resp.State.WordListID != ids[1] {
+		t.Errorf("Expected new session on list %d, got %d", ids[1], resp.State.WordListID)
+	}
+}
+
+func TestAccentedWordList(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+	wordList, err := wordBuilderService.WordListService.CreateWordList([]byte("año\nniño\nçà\n"), "español", "", "test", models.NormalizeOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create word list: %v", err)
+	}
+
+	var info struct {
+		Alphabet []string `json:"alphabet"`
+	}
+	w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d", wordList.ID), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil || len(info.Alphabet) != 7 {
+		t.Fatalf("Expected 7 letters in the alphabet, got %s", w.Body.String())
+	}
+
+	sessionID := initSession(t, router, wordList.ID)
+	for _, move := range []gin.H{{"letter": "a", "position": "prefix"}, {"letter": "ñ", "position": "suffix"}, {"letter": "O", "position": "suffix"}} {
+		move["session_id"] = sessionID
+		if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", move); w.Code != http.StatusOK {
+			t.Fatalf("Adding %q returned %d: %s", move["letter"], w.Code, w.Body.String())
+		}
+	}
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/remove", gin.H{"session_id": sessionID, "index": 1})
+	var resp struct {
+		State struct {
+			Answer string `json:"answer"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.State.Answer != "ao" {
+		t.Errorf("Expected 'ao' after removing 'ñ', got %s", w.Body.String())
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "z", "position": "suffix"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Adding a letter outside the alphabet returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "ño", "position": "suffix"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Adding two letters returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestInsertLetter(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+	sessionID := initSession(t, router, 0)
+	doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "c", "position": "prefix"})
+	doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "t", "position": "suffix"})
+
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "a", "position": "insert"}); w.Code != http.StatusBadRequest {
+		t.Errorf("insert without an index returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "a", "position": "insert", "index": 1})
+	var resp struct {
+		State struct {
+			Answer      string `json:"answer"`
+			IsValidWord bool   `json:"is_valid_word"`
+			GapSets     []struct {
+				Index   int      `json:"index"`
+				Letters []string `json:"letters"`
+			} `json:"gap_sets"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("insert returned %d: %s", w.Code, w.Body.String())
+	}
+	if resp.State.Answer != "cat" || !resp.State.IsValidWord || len(resp.State.GapSets) != 2 {
+		t.Errorf("Expected 'cat' with two gaps, got %+v", resp.State)
+	}
+
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "puzzle", "difficulty": "easy"})
+	var puzzle struct {
+		SessionID string `json:"session_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &puzzle)
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": puzzle.SessionID, "letter": "a", "position": "insert", "index": 0}); w.Code != http.StatusBadRequest {
+		t.Errorf("insert in a puzzle returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestInitSessionUnknownWordList(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"word_list_id": 999})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("init with unknown word list returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestGhostSession(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "ghost", "difficulty": "impossible"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("init with unknown difficulty returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "ghost", "difficulty": "perfect"})
+	var resp struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode init response: %v", err)
+	}
+
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": resp.SessionID, "letter": "c", "position": "prefix"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("add returned %d: %s", w.Code, w.Body.String())
+	}
+	// The computer answered with a letter of its own
+	var state struct {
+		State struct {
+			Answer string `json:"answer"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Failed to decode add response: %v", err)
+	}
+	if len(state.State.Answer) != 2 {
+		t.Errorf("Expected the computer to add a letter, got answer %q", state.State.Answer)
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/undo", gin.H{"session_id": resp.SessionID}); w.Code != http.StatusBadRequest {
+		t.Errorf("undo in a ghost game returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/challenge", gin.H{"session_id": resp.SessionID}); w.Code != http.StatusOK {
+		t.Errorf("challenge returned %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestScoreAccumulatesAcrossWords(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+	sessionID := initSession(t, router, 0)
+
+	var resp struct {
+		State struct {
+			Score struct {
+				Total     int `json:"total"`
+				Penalties int `json:"penalties"`
+				Words     []struct {
+					Word string `json:"word"`
+				} `json:"words"`
+			} `json:"score"`
+		} `json:"state"`
+	}
+	play := func(path string, body gin.H) {
+		t.Helper()
+		body["session_id"] = sessionID
+		w := doJSON(router, http.MethodPost, path, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s returned %d: %s", path, w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+
+	play("/api/wordbuilder/add", gin.H{"letter": "c", "position": "prefix"})
+	play("/api/wordbuilder/add", gin.H{"letter": "a", "position": "suffix"})
+	play("/api/wordbuilder/add", gin.H{"letter": "t", "position": "suffix"})
+	if resp.State.Score.Total != 5 {
+		t.Errorf("Expected 5 points for 'cat', got %d", resp.State.Score.Total)
+	}
+
+	// The score survives a reset and removals cost points
+	play("/api/wordbuilder/reset", gin.H{})
+	play("/api/wordbuilder/add", gin.H{"letter": "c", "position": "prefix"})
+	play("/api/wordbuilder/add", gin.H{"letter": "a", "position": "suffix"})
+	play("/api/wordbuilder/add", gin.H{"letter": "r", "position": "suffix"})
+	play("/api/wordbuilder/remove", gin.H{"index": 2})
+	if got := resp.State.Score; got.Total != 9 || got.Penalties != 1 || len(got.Words) != 2 {
+		t.Errorf("Expected 'cat' and 'car' for 9 points after a penalty, got %+v", got)
+	}
+}
+
+func TestHintsCostPoints(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+	sessionID := initSession(t, router, 0)
+	doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "c", "position": "prefix"})
+	doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "a", "position": "suffix"})
+
+	var resp struct {
+		Message string `json:"message"`
+		State   struct {
+			Hints []struct {
+				Level  int    `json:"level"`
+				Target string `json:"target"`
+			} `json:"hints"`
+			Score struct {
+				Total int `json:"total"`
+				Hints int `json:"hints"`
+			} `json:"score"`
+		} `json:"state"`
+	}
+	for level := 1; level <= 4; level++ {
+		w := doJSON(router, http.MethodPost, "/api/wordbuilder/hint", gin.H{"session_id": sessionID})
+		if w.Code != http.StatusOK {
+			t.Fatalf("hint returned %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode hint response: %v", err)
+		}
+	}
+	if got := resp.State.Score; got.Hints != 4 || got.Total != -12 {
+		t.Errorf("Expected 4 hints costing 12 points, got %+v", got)
+	}
+	if len(resp.State.Hints) != 4 || resp.State.Hints[0].Target != "" || resp.State.Hints[3].Target != "car" {
+		t.Errorf("Expected the hints to lead to 'car', got %+v", resp.State.Hints)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/hint", gin.H{"session_id": sessionID}); w.Code != http.StatusBadRequest {
+		t.Errorf("hint past the last level returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "ghost"})
+	var ghost struct {
+		SessionID string `json:"session_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &ghost)
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/hint", gin.H{"session_id": ghost.SessionID}); w.Code != http.StatusBadRequest {
+		t.Errorf("hint in a ghost game returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestTimedRoundRejectsLateMoves(t *testing.T) {
+	router, _, wordBuilderService := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"duration_seconds": -5})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("init with a negative duration returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	sessionID := "timed"
+	if _, err := wordBuilderService.CreateSession(sessionID, services.SessionOptions{Duration: 100 * time.Millisecond}, services.NewDictionaryService()); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	for _, letter := range []string{"c", "a", "t"} {
+		position := "suffix"
+		if letter == "c" {
+			position = "prefix"
+		}
+		if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": letter, "position": position}); w.Code != http.StatusOK {
+			t.Fatalf("add returned %d: %s", w.Code, w.Body.String())
+		}
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "s", "position": "prefix"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("add after expiry returned %d, want %d", w.Code, http.StatusConflict)
+	}
+	var resp struct {
+		State struct {
+			Answer string `json:"answer"`
+			Timer  struct {
+				Expired          bool     `json:"expired"`
+				RemainingSeconds float64  `json:"remaining_seconds"`
+				Found            []string `json:"found"`
+			} `json:"timer"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	timer := resp.State.Timer
+	if resp.State.Answer != "cat" || !timer.Expired || timer.RemainingSeconds != 0 || len(timer.Found) != 1 || timer.Found[0] != "cat" {
+		t.Errorf("Expected the round over with 'cat' found, got %+v", resp.State)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/remove", gin.H{"session_id": sessionID, "index": 0}); w.Code != http.StatusConflict {
+		t.Errorf("remove after expiry returned %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	// The final score stands, as the clock can't be restarted once it ran out
+	final, _ := wordBuilderService.GetSession(sessionID)
+	if final.Score.Total == 0 {
+		t.Fatal("Expected 'cat' to have scored")
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/reset", gin.H{"session_id": sessionID}); w.Code != http.StatusConflict {
+		t.Errorf("reset after expiry returned %d, want %d", w.Code, http.StatusConflict)
+	}
+	if state, _ := wordBuilderService.GetSession(sessionID); state.Score.Total != final.Score.Total || !state.Timer.Expired(time.Now()) {
+		t.Errorf("Expected the final score %d to stand, got %+v", final.Score.Total, state.Score)
+	}
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/reset", gin.H{"session_id": "missing"}); w.Code != http.StatusNotFound {
+		t.Errorf("reset of a missing session returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestPuzzleSession(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "puzzle", "difficulty": "hard", "min_length": 20})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("init without a long enough word returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	type puzzleResponse struct {
+		SessionID string `json:"session_id"`
+		State     struct {
+			Answer    string   `json:"answer"`
+			PrefixSet []string `json:"prefix_set"`
+			SuffixSet []string `json:"suffix_set"`
+			Puzzle    struct {
+				Length int    `json:"length"`
+				Solved bool   `json:"solved"`
+				Target string `json:"target"`
+			} `json:"puzzle"`
+		} `json:"state"`
+	}
+	var resp puzzleResponse
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"mode": "puzzle", "difficulty": "easy"})
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode init response: %v", err)
+	}
+	sessionID := resp.SessionID
+	if resp.State.Puzzle.Length < 3 || resp.State.Puzzle.Target != "" {
+		t.Fatalf("Expected a hidden target of at least 3 letters, got %+v", resp.State.Puzzle)
+	}
+
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/remove", gin.H{"session_id": sessionID, "index": 0}); w.Code != http.StatusBadRequest {
+		t.Errorf("remove in a puzzle returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Following the only letters offered solves the puzzle
+	for !resp.State.Puzzle.Solved {
+		body := gin.H{"session_id": sessionID}
+		switch {
+		case len(resp.State.SuffixSet) > 0:
+			body["letter"], body["position"] = resp.State.SuffixSet[0], "suffix"
+		case len(resp.State.PrefixSet) > 0:
+			body["letter"], body["position"] = resp.State.PrefixSet[0], "prefix"
+		default:
+			t.Fatalf("No letter offered for %q", resp.State.Answer)
+		}
+		w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("add returned %d: %s", w.Code, w.Body.String())
+		}
+		resp = puzzleResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode add response: %v", err)
+		}
+	}
+	if resp.State.Puzzle.Target != resp.State.Answer || len(resp.State.Answer) != resp.State.Puzzle.Length {
+		t.Errorf("Expected the target revealed once solved, got %+v", resp.State)
+	}
+}
+
+func TestRackSession(t *testing.T) {
+	router, ids, _ := newTestRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"word_list_id": ids[0], "mode": "rack", "rack": "TRAC"})
+	var resp struct {
+		SessionID string `json:"session_id"`
+		State     struct {
+			Answer    string   `json:"answer"`
+			PrefixSet []string `json:"prefix_set"`
+			Rack      struct {
+				Tiles     []string `json:"tiles"`
+				Remaining []string `json:"remaining"`
+				Supplied  bool     `json:"supplied"`
+			} `json:"rack"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("init returned %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.State.Rack.Supplied || strings.Join(resp.State.Rack.Tiles, "") != "acrt" {
+		t.Errorf("Expected the supplied rack, got %+v", resp.State.Rack)
+	}
+	for _, letter := range resp.State.PrefixSet {
+		if !strings.Contains("acrt", letter) {
+			t.Errorf("Letter '%s' is not on the rack", letter)
+		}
+	}
+	sessionID := resp.SessionID
+
+	doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "c", "position": "prefix"})
+	if w := doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "c", "position": "suffix"}); w.Code != http.StatusBadRequest {
+		t.Errorf("adding a used tile returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/add", gin.H{"session_id": sessionID, "letter": "a", "position": "suffix"})
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if strings.Join(resp.State.Rack.Remaining, "") != "rt" {
+		t.Errorf("Expected r and t left, got %v", resp.State.Rack.Remaining)
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/wordbuilder/rack/solve?session_id="+sessionID+"&limit=2", nil)
+	var solved struct {
+		Words []models.WordScore `json:"words"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &solved); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("solve returned %d: %s", w.Code, w.Body.String())
+	}
+	if len(solved.Words) != 2 || solved.Words[0].Word != "cart" {
+		t.Errorf("Expected 'cart' first of two words, got %+v", solved.Words)
+	}
+
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordbuilder/rack/solve?word_list_id=%d&letters=godx", ids[1]), nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("solving letters outside the alphabet returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordbuilder/rack/solve?word_list_id=%d&letters=god", ids[1]), nil)
+	json.Unmarshal(w.Body.Bytes(), &solved)
+	if len(solved.Words) != 2 {
+		t.Errorf("Expected 'dog' and 'god', got %+v", solved.Words)
+	}
+
+	if w := doJSON(router, http.MethodGet, "/api/wordbuilder/rack/solve?session_id="+initSession(t, router, 0), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("solving a session without a rack returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = doJSON(router, http.MethodPost, "/api/wordbuilder/init", gin.H{"word_list_id": ids[0], "mode": "rack", "distribution": gin.H{"a": 1000000000}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("init with a huge distribution returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -3,15 +3,23 @@ package controllers
 import (
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
+	"wordbuilder/models"
 	"wordbuilder/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxSearchLimit bounds the words returned by a page of a pattern search
+const maxSearchLimit = 1000
+
+// maxDecompressionRatio bounds how much larger than the upload limit a
+// gzipped word list may grow once decompressed
+const maxDecompressionRatio = 20
+
 // WordListController handles HTTP requests for word list management
 type WordListController struct {
 	WordListService    *services.WordListService
@@ -56,12 +64,27 @@ func (c *WordListController) GetWordList(ctx *gin.Context) {
 		return
 	}
 
+	// The alphabet tells clients which letters to offer for this list. It is
+	// kept with the list, except for lists saved before it was, which have
+	// it once loaded.
+	alphabet := wordList.Alphabet
+	if alphabet == nil {
+		dictionary, err := c.WordListService.LoadWordListIntoDictionary(id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+			return
+		}
+		alphabet = dictionary.GetAlphabet()
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"word_list": wordList,
+		"alphabet":  alphabet,
 	})
 }
 
-// GetWordListSample returns a sample of words from a word list
+// GetWordListSample returns a sample of words from a word list, with how
+// common each is when the list says
 func (c *WordListController) GetWordListSample(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -76,16 +99,73 @@ func (c *WordListController) GetWordListSample(ctx *gin.Context) {
 		limit = 100
 	}
 
-	words, err := c.WordListService.ReadWordListContent(id, limit)
+	words, frequencies, err := c.WordListService.SampleWordList(id, limit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to read word list: %v", err)})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"words": words,
 		"count": len(words),
-	})
+	}
+	// Only lists with a frequency column know how common their words are
+	if frequencies != nil {
+		response["frequencies"] = frequencies
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SearchWordList lists the words of a word list matching a wildcard pattern,
+// a page at a time. Pages are read by offset, or after the last word of the
+// previous page, which doesn't walk the words before it again.
+func (c *WordListController) SearchWordList(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word list ID"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	dictionary, err := c.WordListService.LoadWordListIntoDictionary(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to load word list: %v", err)})
+		return
+	}
+	pattern := ctx.Query("pattern")
+	after := ctx.Query("after")
+	// One word past the page tells whether there are more
+	words, err := dictionary.FindWordsMatchingAfter(pattern, after, offset+limit+1)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid pattern: %v", err)})
+		return
+	}
+
+	page := words[min(offset, len(words)):min(offset+limit, len(words))]
+	response := gin.H{
+		"pattern":  pattern,
+		"words":    page,
+		"count":    len(page),
+		"offset":   offset,
+		"limit":    limit,
+		"has_more": len(words) > offset+limit,
+	}
+	// The last word of the page is where the next page starts after
+	if len(words) > offset+limit {
+		response["next"] = page[len(page)-1]
+	}
+	ctx.JSON(http.StatusOK, response)
 }
 
 // CreateWordList creates a new word list from an uploaded file
@@ -118,22 +198,21 @@ func (c *WordListController) CreateWordList(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	// Validate file extension
-	ext := filepath.Ext(header.Filename)
-	if ext != ".txt" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only .txt files are allowed"})
+	// Read file content, in whichever format it comes
+	fileData, status, err := c.readUpload(ctx, file, header)
+	if err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Read file content
-	fileData, err := io.ReadAll(file)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to read file: %v", err)})
+	var opts models.NormalizeOptions
+	if _, err := normalizeOptionsFromForm(ctx, &opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Create word list
-	wordList, err := c.WordListService.CreateWordList(fileData, name, description, source)
+	wordList, err := c.WordListService.CreateWordList(fileData, name, description, source, opts)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create word list: %v", err)})
 		return
@@ -181,23 +260,34 @@ func (c *WordListController) UpdateWordList(ctx *gin.Context) {
 	if err == nil {
 		defer file.Close()
 
-		// Validate file extension
-		ext := filepath.Ext(header.Filename)
-		if ext != ".txt" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only .txt files are allowed"})
-			return
-		}
-
-		// Read file content
-		fileData, err = io.ReadAll(file)
+		// Read file content, in whichever format it comes
+		var status int
+		fileData, status, err = c.readUpload(ctx, file, header)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to read file: %v", err)})
+			ctx.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
 	}
 
+	// Options left out of the form keep their current values
+	existing, err := c.WordListService.GetWordList(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Word list not found: %v", err)})
+		return
+	}
+	opts := existing.Normalization
+	changed, err := normalizeOptionsFromForm(ctx, &opts)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var newOpts *models.NormalizeOptions
+	if changed {
+		newOpts = &opts
+	}
+
 	// Update word list
-	wordList, err := c.WordListService.UpdateWordList(id, name, description, source, fileData)
+	wordList, err := c.WordListService.UpdateWordList(id, name, description, source, fileData, newOpts)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update word list: %v", err)})
 		return
@@ -277,7 +367,7 @@ func (c *WordListController) DownloadWordList(ctx *gin.Context) {
 	ctx.File(wordList.FilePath)
 }
 
-// UseWordList sets a word list as the active dictionary
+// UseWordList sets a word list as the default dictionary for new sessions
 func (c *WordListController) UseWordList(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -293,15 +383,82 @@ func (c *WordListController) UseWordList(ctx *gin.Context) {
 		return
 	}
 
-	// Update the word builder service with the new dictionary
-	// This is the critical part - we need to update the service's dictionary
-	c.WordBuilderService.UpdateDictionary(dictionary)
+	// New sessions started without a word list will use this dictionary,
+	// sessions already in progress keep their own
+	c.WordBuilderService.UpdateDictionary(id, dictionary)
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Word list loaded successfully with %d words", len(dictionary.WordList)),
+		"message": fmt.Sprintf("Word list loaded successfully with %d words", dictionary.WordCount()),
 	})
 }
 
+// readUpload reads an uploaded word list file as text with one entry per
+// line, converting it from its format. The format goes by the extension
+// unless the form sets "format", and "word_column" and "frequency_column"
+// pick the columns of CSV and TSV files or the fields of JSON objects.
+func (c *WordListController) readUpload(ctx *gin.Context, file multipart.File, header *multipart.FileHeader) ([]byte, int, error) {
+	opts := models.ImportOptions{
+		Format:          ctx.Request.FormValue("format"),
+		WordColumn:      ctx.Request.FormValue("word_column"),
+		FrequencyColumn: ctx.Request.FormValue("frequency_column"),
+		MaxSize:         c.MaxFileSize * maxDecompressionRatio,
+	}
+	if _, err := models.NewWordListReader(header.Filename, opts); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Only .txt, .csv, .tsv and .json files are allowed, optionally gzipped")
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to read file: %v", err)
+	}
+	converted, err := models.ConvertWordList(data, header.Filename, opts)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Failed to read %s: %v", header.Filename, err)
+	}
+	return converted, http.StatusOK, nil
+}
+
+// normalizeOptionsFromForm sets the normalization options given in an upload
+// form on opts, and reports whether the form set any
+func normalizeOptionsFromForm(ctx *gin.Context, opts *models.NormalizeOptions) (bool, error) {
+	form := ctx.Request.Form
+	changed := false
+
+	if values, ok := form["normalization_form"]; ok {
+		opts.Form, changed = values[0], true
+	}
+	if values, ok := form["frequency"]; ok {
+		opts.Frequency, changed = values[0], true
+	}
+	for key, flag := range map[string]*bool{
+		"fold_diacritics":   &opts.FoldDiacritics,
+		"strip_punctuation": &opts.StripPunctuation,
+		"strip_digits":      &opts.StripDigits,
+		"reject_multi_word": &opts.RejectMultiWord,
+	} {
+		if values, ok := form[key]; ok {
+			value, err := strconv.ParseBool(values[0])
+			if err != nil {
+				return false, fmt.Errorf("invalid %s '%s'", key, values[0])
+			}
+			*flag, changed = value, true
+		}
+	}
+	for key, length := range map[string]*int{
+		"min_length": &opts.MinLength,
+		"max_length": &opts.MaxLength,
+	} {
+		if values, ok := form[key]; ok {
+			value, err := strconv.Atoi(values[0])
+			if err != nil {
+				return false, fmt.Errorf("invalid %s '%s'", key, values[0])
+			}
+			*length, changed = value, true
+		}
+	}
+	return changed, opts.Validate()
+}
+
 // RegisterRoutes registers all controller routes
 func (c *WordListController) RegisterRoutes(router *gin.Engine) {
 	api := router.Group("/api/wordlists")
@@ -309,6 +466,7 @@ func (c *WordListController) RegisterRoutes(router *gin.Engine) {
 		api.GET("", c.GetAllWordLists)
 		api.GET("/:id", c.GetWordList)
 		api.GET("/:id/sample", c.GetWordListSample)
+		api.GET("/:id/search", c.SearchWordList)
 		api.POST("", c.CreateWordList)
 		api.PUT("/:id", c.UpdateWordList)
 		api.DELETE("/:id", c.DeleteWordList)
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"wordbuilder/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doMultipart sends a form with an optional word list file
+func doMultipart(router *gin.Engine, method, path string, fields map[string]string, file string) *httptest.ResponseRecorder {
+	return doUpload(router, method, path, fields, "words.txt", []byte(file))
+}
+
+// doUpload sends a form with an optional word list file of any name
+func doUpload(router *gin.Engine, method, path string, fields map[string]string, filename string, file []byte) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	if len(file) > 0 {
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write(file)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWordListNormalization(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	type wordListResponse struct {
+		WordList struct {
+			ID            int      `json:"id"`
+			WordCount     int      `json:"word_count"`
+			FilePath      string   `json:"file_path"`
+			Alphabet      []string `json:"alphabet"`
+			Normalization struct {
+				FoldDiacritics bool `json:"fold_diacritics"`
+				MinLength      int  `json:"min_length"`
+			} `json:"normalization"`
+		} `json:"word_list"`
+		Alphabet []string `json:"alphabet"`
+	}
+	decode := func(w *httptest.ResponseRecorder) wordListResponse {
+		t.Helper()
+		var resp wordListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response %s: %v", w.Body.String(), err)
+		}
+		return resp
+	}
+
+	file := "Café\nCAFE\ndon't\nice cream\nab\n"
+	if w := doMultipart(router, http.MethodPost, "/api/wordlists", map[string]string{"name": "bad", "min_length": "many"}, file); w.Code != http.StatusBadRequest {
+		t.Errorf("create with an invalid length returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w := doMultipart(router, http.MethodPost, "/api/wordlists", map[string]string{
+		"name":              "french",
+		"fold_diacritics":   "true",
+		"strip_punctuation": "true",
+		"reject_multi_word": "true",
+		"min_length":        "3",
+	}, file)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create returned %d: %s", w.Code, w.Body.String())
+	}
+	created := decode(w)
+	if created.WordList.WordCount != 2 {
+		t.Errorf("Expected 'cafe' and 'dont', got %d words", created.WordList.WordCount)
+	}
+
+	// The options are stored, so loading the list gives the same words
+	path := fmt.Sprintf("/api/wordlists/%d", created.WordList.ID)
+	got := decode(doJSON(router, http.MethodGet, path, nil))
+	if !got.WordList.Normalization.FoldDiacritics || got.WordList.Normalization.MinLength != 3 {
+		t.Errorf("Expected the options to be stored, got %+v", got.WordList.Normalization)
+	}
+	if !slices.Equal(got.Alphabet, []string{"a", "c", "d", "e", "f", "n", "o", "t"}) {
+		t.Errorf("Unexpected alphabet %v", got.Alphabet)
+	}
+
+	// Changing one option keeps the others
+	w = doMultipart(router, http.MethodPut, path, map[string]string{"name": "french", "fold_diacritics": "false"}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("update returned %d: %s", w.Code, w.Body.String())
+	}
+	if updated := decode(w); updated.WordList.WordCount != 3 || updated.WordList.Normalization.MinLength != 3 {
+		t.Errorf("Expected 'café', 'cafe' and 'dont', got %+v", updated.WordList)
+	}
+	if got := decode(doJSON(router, http.MethodGet, path, nil)); len(got.Alphabet) != 9 {
+		t.Errorf("Expected the reloaded list to keep 'é', got %v", got.Alphabet)
+	}
+
+	// The alphabet is stored with the list, so the metadata doesn't need
+	// the words
+	if err := os.Remove(created.WordList.FilePath); err != nil {
+		t.Fatalf("Failed to remove the word list file: %v", err)
+	}
+	w = doJSON(router, http.MethodGet, path, nil)
+	if got := decode(w); w.Code != http.StatusOK || len(got.Alphabet) != 9 || len(got.WordList.Alphabet) != 9 {
+		t.Errorf("Expected the stored alphabet, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSearchWordList(t *testing.T) {
+	router, ids, _ := newTestRouter(t)
+
+	var resp struct {
+		Words   []string `json:"words"`
+		HasMore bool     `json:"has_more"`
+		Next    string   `json:"next"`
+	}
+	w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/search?pattern=*a*&limit=2", ids[0]), nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("search returned %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.HasMore || resp.Next != "car" || !slices.Equal(resp.Words, []string{"act", "car"}) {
+		t.Errorf("Expected act and car with more to come, got %+v", resp)
+	}
+
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/search?pattern=*a*&after=%s&limit=2", ids[0], resp.Next), nil)
+	resp.Next = ""
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.HasMore || !slices.Equal(resp.Words, []string{"cart", "cat"}) {
+		t.Errorf("Expected the page after car to hold cart and cat, got %+v", resp)
+	}
+
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/search?pattern=*a*&offset=4&limit=2", ids[0]), nil)
+	resp.Next = ""
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.HasMore || resp.Next != "" || !slices.Equal(resp.Words, []string{"scat"}) {
+		t.Errorf("Expected the last page to hold scat, got %+v", resp)
+	}
+
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/search?pattern=d[^a]?", ids[1]), nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !slices.Equal(resp.Words, []string{"dog", "dot"}) {
+		t.Errorf("Expected dog and dot, got %+v", resp)
+	}
+
+	if w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/search?pattern=c[at", ids[0]), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid pattern returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestWordListSnapshots(t *testing.T) {
+	router, ids, wordBuilderService := newTestRouter(t)
+	wordListService := wordBuilderService.WordListService
+
+	// Loading the list took a snapshot next to its file
+	wordList, _ := wordListService.GetWordList(ids[0])
+	snapshot := wordList.FilePath + ".snapshot"
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("Expected a snapshot after loading the list: %v", err)
+	}
+
+	// Replacing the file drops the snapshot, and the next load takes a new one
+	path := fmt.Sprintf("/api/wordlists/%d", ids[0])
+	if w := doMultipart(router, http.MethodPut, path, map[string]string{"name": "list 0"}, "cow\ncows\n"); w.Code != http.StatusOK {
+		t.Fatalf("update returned %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(snapshot); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the old snapshot to be removed, got %v", err)
+	}
+	wordList, _ = wordListService.GetWordList(ids[0])
+	snapshot = wordList.FilePath + ".snapshot"
+
+	// A fresh service, as after a restart, reads the snapshot back and
+	// rebuilds it when it is damaged
+	for _, damage := range []bool{false, true} {
+		restarted := services.NewWordListService(wordListService.DBService, wordListService.DictionaryService, wordListService.UploadDir)
+		if damage {
+			data, _ := os.ReadFile(snapshot)
+			data[len(data)/2] ^= 0xff
+			os.WriteFile(snapshot, data, 0644)
+		}
+		dictionary, err := restarted.LoadWordListIntoDictionary(ids[0])
+		if err != nil || !slices.Equal(dictionary.WordList, []string{"cow", "cows"}) {
+			t.Fatalf("Expected cow and cows, got %v: %v", dictionary, err)
+		}
+		if _, err := os.Stat(snapshot); err != nil {
+			t.Errorf("Expected a snapshot of the new file: %v", err)
+		}
+	}
+
+	if w := doJSON(router, http.MethodDelete, path, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete returned %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(snapshot); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the snapshot to be removed with the list, got %v", err)
+	}
+}
+
+func TestWordListFrequencies(t *testing.T) {
+	router, ids, _ := newTestRouter(t)
+
+	type sampleResponse struct {
+		Words       []string           `json:"words"`
+		Frequencies map[string]float64 `json:"frequencies"`
+	}
+	sample := func(id int) sampleResponse {
+		t.Helper()
+		var resp sampleResponse
+		w := doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/sample?limit=3", id), nil)
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("sample returned %d: %s", w.Code, w.Body.String())
+		}
+		return resp
+	}
+
+	if resp := sample(ids[0]); resp.Frequencies != nil || !slices.Equal(resp.Words, []string{"cat", "car", "cart"}) {
+		t.Errorf("Expected words without frequencies, got %+v", resp)
+	}
+
+	w := doMultipart(router, http.MethodPost, "/api/wordlists", map[string]string{"name": "ranked", "frequency": "rank"},
+		"Apple\t1\naardwolf\t5000\nbanana\t20\n")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create returned %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		WordList struct {
+			ID int `json:"id"`
+		} `json:"word_list"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	resp := sample(created.WordList.ID)
+	if !slices.Equal(resp.Words, []string{"apple", "aardwolf", "banana"}) {
+		t.Errorf("Expected the frequency column to be split off, got %v", resp.Words)
+	}
+	if resp.Frequencies["apple"] != 1 || !(resp.Frequencies["banana"] > resp.Frequencies["aardwolf"]) {
+		t.Errorf("Expected frequencies to follow the ranks, got %v", resp.Frequencies)
+	}
+
+	if w := doMultipart(router, http.MethodPost, "/api/wordlists", map[string]string{"name": "bad", "frequency": "percent"}, "apple\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("create with an unknown frequency column returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestWordListFormats(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	if w := doUpload(router, http.MethodPost, "/api/wordlists", map[string]string{"name": "sheet"}, "words.xlsx", []byte("apple")); w.Code != http.StatusBadRequest {
+		t.Errorf("create with an unsupported format returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := doUpload(router, http.MethodPost, "/api/wordlists", map[string]string{"name": "bad", "word_column": "word"}, "words.csv", []byte("lemma\napple\n")); w.Code != http.StatusBadRequest {
+		t.Errorf("create with a missing column returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Columns of a gzipped TSV file picked by name
+	var tabbed bytes.Buffer
+	gz := gzip.NewWriter(&tabbed)
+	gz.Write([]byte("lemma\tpos\tn\nbanana\tnoun\t20\napple\tnoun\t1\n"))
+	gz.Close()
+	w := doUpload(router, http.MethodPost, "/api/wordlists", map[string]string{
+		"name":             "ranked",
+		"word_column":      "lemma",
+		"frequency_column": "n",
+		"frequency":        "rank",
+	}, "words.tsv.gz", tabbed.Bytes())
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create returned %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		WordList struct {
+			ID        int `json:"id"`
+			WordCount int `json:"word_count"`
+		} `json:"word_list"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.WordList.WordCount != 2 {
+		t.Errorf("Expected 2 words, got %d", created.WordList.WordCount)
+	}
+
+	var sample struct {
+		Words       []string           `json:"words"`
+		Frequencies map[string]float64 `json:"frequencies"`
+	}
+	w = doJSON(router, http.MethodGet, fmt.Sprintf("/api/wordlists/%d/sample", created.WordList.ID), nil)
+	json.Unmarshal(w.Body.Bytes(), &sample)
+	if !slices.Equal(sample.Words, []string{"banana", "apple"}) || !(sample.Frequencies["apple"] > sample.Frequencies["banana"]) {
+		t.Errorf("Expected banana and apple ranked from the n column, got %+v", sample)
+	}
+
+	// Replacing the file takes any format as well
+	path := fmt.Sprintf("/api/wordlists/%d", created.WordList.ID)
+	w = doUpload(router, http.MethodPut, path, map[string]string{"name": "ranked"}, "words.json", []byte(`[{"word": "cherry", "rank": 2}]`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update returned %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(router, http.MethodGet, path+"/sample", nil)
+	json.Unmarshal(w.Body.Bytes(), &sample)
+	if !slices.Equal(sample.Words, []string{"cherry"}) {
+		t.Errorf("Expected cherry after the update, got %+v", sample)
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"log"
+	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -25,6 +28,13 @@ func main() {
 
 	// Initialize dictionary service
 	dictService := services.NewDictionaryService()
+	if compact := os.Getenv("WORDBUILDER_COMPACT_DICTIONARIES"); compact != "" {
+		if on, err := strconv.ParseBool(compact); err == nil {
+			dictService.Options.Compact = on
+		} else {
+			log.Printf("Ignoring invalid WORDBUILDER_COMPACT_DICTIONARIES %q: %v", compact, err)
+		}
+	}
 
 	// Initialize word list service
 	wordListService := services.NewWordListService(dbService, dictService, uploadsDir)
@@ -34,6 +44,7 @@ func main() {
 
 	// Initialize dictionary - either from existing word list or default
 	var dictionary *models.WordDictionary
+	defaultWordListID := 0
 
 	if err == nil && len(wordLists) > 0 {
 		// Use the most recently updated word list
@@ -42,12 +53,35 @@ func main() {
 			log.Printf("Failed to load existing word list, falling back to default: %v", err)
 		} else {
 			log.Printf("Loaded dictionary from word list '%s' with %d words\n",
-				wordLists[0].Name, len(dictionary.WordList))
+				wordLists[0].Name, dictionary.WordCount())
+			defaultWordListID = wordLists[0].ID
 		}
 	}
 
 	// Initialize services
-	wordBuilderService := services.NewWordBuilderService(dictionary)
+	wordBuilderService := services.NewWordBuilderService(dictionary, defaultWordListID, dbService, wordListService)
+
+	// Session limits can be tuned per deployment
+	if ttl := os.Getenv("WORDBUILDER_SESSION_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			wordBuilderService.SessionTTL = d
+		} else {
+			log.Printf("Ignoring invalid WORDBUILDER_SESSION_TTL %q: %v", ttl, err)
+		}
+	}
+	if limit := os.Getenv("WORDBUILDER_MAX_SESSIONS"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil {
+			wordBuilderService.MaxSessions = n
+		} else {
+			log.Printf("Ignoring invalid WORDBUILDER_MAX_SESSIONS %q: %v", limit, err)
+		}
+	}
+	stopReaper := wordBuilderService.StartReaper(time.Minute)
+	defer stopReaper()
+
+	roomService := services.NewRoomService(wordBuilderService)
+	stopRoomReaper := roomService.StartReaper(time.Minute)
+	defer stopRoomReaper()
 
 	// Initialize settings controller
 	settingsController := controllers.NewSettingsController(dataDir, dbService)
@@ -55,7 +89,9 @@ func main() {
 	// Initialize controllers
 	wordBuilderController := controllers.NewWordBuilderController(wordBuilderService)
 	wordListController := controllers.NewWordListController(wordListService, wordBuilderService)
-	dictionaryController := controllers.NewDictionaryController(settingsController)
+	roomController := controllers.NewRoomController(roomService)
+	puzzleController := controllers.NewPuzzleController(wordBuilderService, dbService)
+	dictionaryController := controllers.NewDictionaryController(settingsController, wordBuilderService)
 
 	// Initialize Gin
 	r := gin.Default()
@@ -73,6 +109,8 @@ func main() {
 	// Register routes
 	wordBuilderController.RegisterRoutes(r)
 	wordListController.RegisterRoutes(r)
+	roomController.RegisterRoutes(r)
+	puzzleController.RegisterRoutes(r)
 	dictionaryController.RegisterRoutes(r)
 	settingsController.RegisterRoutes(r) // Register the settings routes
 
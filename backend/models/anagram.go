@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// AnagramBlank stands for any letter in anagram searches, like a blank tile
+const AnagramBlank = '?'
+
+// Bounds on anagram searches, as each blank multiplies the words to visit
+const (
+	MaxAnagramLetters = 20
+	MaxAnagramBlanks  = 3
+)
+
+// AnagramMatch is a word that can be spelled with some of the letters
+type AnagramMatch struct {
+	Word   string `json:"word"`
+	Blanks []int  `json:"blanks,omitempty"` // Rune indexes of the letters played by blanks
+	Exact  bool   `json:"exact"`            // Set when the word uses every letter
+}
+
+// Signature sorts the letters of a word, so anagrams share a signature
+func Signature(word string) string {
+	runes := []rune(word)
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return string(runes)
+}
+
+// ParseAnagramLetters splits letters into the runes to spell with and the
+// number of blanks, checking each belongs to the dictionary's alphabet
+func ParseAnagramLetters(dict WordDictionaryI, letters string) ([]rune, int, error) {
+	letters = strings.ToLower(letters)
+	count := utf8.RuneCountInString(letters)
+	if count == 0 || count > MaxAnagramLetters {
+		return nil, 0, fmt.Errorf("letters must be between 1 and %d long", MaxAnagramLetters)
+	}
+
+	var runes []rune
+	blanks := 0
+	for _, ch := range letters {
+		switch {
+		case ch == AnagramBlank:
+			blanks++
+		case IsLetter(dict, string(ch)):
+			runes = append(runes, ch)
+		default:
+			return nil, 0, fmt.Errorf("'%c' is not a letter of this word list", ch)
+		}
+	}
+	if blanks > MaxAnagramBlanks {
+		return nil, 0, fmt.Errorf("at most %d blanks are allowed", MaxAnagramBlanks)
+	}
+	return runes, blanks, nil
+}
+
+// Anagrams returns the words spelled with exactly the given letters, sorted
+func (d *WordDictionary) Anagrams(letters string) []string {
+	runes := []rune(strings.ToLower(letters))
+	words := []string{}
+	for _, match := range d.SubAnagrams(runes, 0, len(runes)) {
+		words = append(words, match.Word)
+	}
+	return words
+}
+
+// SubAnagrams returns the words of at least minLength letters spelled with
+// some of the letters and up to blanks wildcard letters. Words come longest
+// first, then in alphabetical order. A blank is only played for a letter
+// that has run out, so each word comes with the fewest blanks it needs.
+func (d *WordDictionary) SubAnagrams(letters []rune, blanks, minLength int) []AnagramMatch {
+	counts := make(map[rune]int, len(letters))
+	for _, ch := range letters {
+		counts[ch]++
+	}
+	total := len(letters) + blanks
+
+	var matches []AnagramMatch
+	var word []rune
+	var blanked []int
+	var walk func(node graphNode, blanksLeft int)
+	walk = func(node graphNode, blanksLeft int) {
+		if node.isWord() && len(word) > 0 && len(word) >= minLength {
+			matches = append(matches, AnagramMatch{
+				Word:   string(word),
+				Blanks: append([]int(nil), blanked...),
+				Exact:  len(word) == total,
+			})
+		}
+		node.eachChild(func(ch rune, child graphNode) {
+			switch {
+			case counts[ch] > 0:
+				counts[ch]--
+				word = append(word, ch)
+				walk(child, blanksLeft)
+				word = word[:len(word)-1]
+				counts[ch]++
+			case blanksLeft > 0:
+				blanked = append(blanked, len(word))
+				word = append(word, ch)
+				walk(child, blanksLeft-1)
+				word = word[:len(word)-1]
+				blanked = blanked[:len(blanked)-1]
+			}
+		})
+	}
+	walk(d.ForwardTrie.root(), blanks)
+
+	sort.Slice(matches, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(matches[i].Word), utf8.RuneCountInString(matches[j].Word)
+		if li != lj {
+			return li > lj
+		}
+		return matches[i].Word < matches[j].Word
+	})
+	return matches
+}
@@ -0,0 +1,57 @@
+package models
+
+import "testing"
+
+func TestAnagrams(t *testing.T) {
+	dict := NewWordDictionary([]string{"listen", "silent", "enlist", "tinsel", "list", "slit", "lit"})
+
+	if got := dict.Anagrams("NETSIL"); !equalStringSlices(got, []string{"enlist", "listen", "silent", "tinsel"}) {
+		t.Errorf("Unexpected anagrams %v", got)
+	}
+	if got := dict.Anagrams("xyz"); len(got) != 0 {
+		t.Errorf("Expected no anagrams, got %v", got)
+	}
+	if Signature("slit") != Signature("list") {
+		t.Error("Expected anagrams to share a signature")
+	}
+}
+
+func TestSubAnagrams(t *testing.T) {
+	dict := newTestDictionary()
+
+	var words []string
+	for _, match := range dict.SubAnagrams([]rune("dnabc"), 0, 2) {
+		words = append(words, match.Word)
+	}
+	if !equalStringSlices(words, []string{"band", "cab", "can"}) {
+		t.Errorf("Expected band, cab and can, got %v", words)
+	}
+
+	// A blank stands in for the last 'a' of "bandana" and "banana"
+	matches := dict.SubAnagrams([]rune("bdanan"), 1, 6)
+	if len(matches) != 2 || matches[0].Word != "bandana" || !matches[0].Exact || len(matches[0].Blanks) != 1 || matches[0].Blanks[0] != 6 {
+		t.Fatalf("Expected 'bandana' with its last letter blank first, got %+v", matches)
+	}
+	if matches[1].Word != "banana" || matches[1].Exact || matches[1].Blanks[0] != 5 {
+		t.Errorf("Expected 'banana' with its last letter blank, got %+v", matches[1])
+	}
+	matches = dict.SubAnagrams([]rune("bnn"), 3, 6)
+	if len(matches) != 1 || matches[0].Word != "banana" || len(matches[0].Blanks) != 3 || !matches[0].Exact {
+		t.Errorf("Expected 'banana' with three blanks, got %+v", matches)
+	}
+}
+
+func TestParseAnagramLetters(t *testing.T) {
+	dict := newTestDictionary()
+
+	letters, blanks, err := ParseAnagramLetters(dict, "Ab??")
+	if err != nil || string(letters) != "ab" || blanks != 2 {
+		t.Errorf("Expected 'ab' and 2 blanks, got %q, %d: %v", string(letters), blanks, err)
+	}
+	if _, _, err := ParseAnagramLetters(dict, "abz"); err == nil {
+		t.Error("Expected an error for a letter outside the alphabet")
+	}
+	if _, _, err := ParseAnagramLetters(dict, "a????"); err == nil {
+		t.Error("Expected an error for too many blanks")
+	}
+}
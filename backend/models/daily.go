@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DailyDateFormat is the layout of the date a daily puzzle is generated for
+const DailyDateFormat = "2006-01-02"
+
+// Bounds on the daily puzzle, chosen so every day has a handful of words to
+// find without being exhausting
+const (
+	dailyMinLength      = 4
+	dailyMaxLength      = 8
+	dailyFragmentLength = 2
+	dailyMinTargets     = 3
+	dailyMaxTargets     = 10
+	dailyAttempts       = 50
+)
+
+// DailyPuzzle is the puzzle of the day for a word list: a seed fragment and
+// the words that can be built from it
+type DailyPuzzle struct {
+	Date       string   `json:"date"`
+	WordListID int      `json:"word_list_id"`
+	Seed       string   `json:"seed"`
+	Targets    []string `json:"targets"` // Sorted, never shown to the player
+}
+
+// DailyState tracks a session playing the daily puzzle
+type DailyState struct {
+	Date    string   `json:"date"`
+	Seed    string   `json:"seed"`
+	Targets []string `json:"targets"`
+	Found   []string `json:"found"` // Targets found so far, in order
+}
+
+// DailyResult is how a session did on a daily puzzle
+type DailyResult struct {
+	SessionID   string     `json:"session_id"`
+	Date        string     `json:"date"`
+	WordListID  int        `json:"word_list_id"`
+	Found       []string   `json:"found"`
+	TargetCount int        `json:"target_count"`
+	Score       int        `json:"score"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// dailySeed derives the random seed of a day's puzzle
+func dailySeed(date string, wordListID int) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s/%d", date, wordListID)
+	return int64(h.Sum64())
+}
+
+// GenerateDailyPuzzle derives the puzzle for a date and word list. It only
+// depends on its arguments and the words in the dictionary, so everyone
+// playing the same list on the same day gets the same puzzle.
+func GenerateDailyPuzzle(dict WordDictionaryI, date string, wordListID int) (DailyPuzzle, error) {
+	if _, err := time.Parse(DailyDateFormat, date); err != nil {
+		return DailyPuzzle{}, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+	}
+
+	// Sorted so the puzzle doesn't depend on the order words were loaded in
+	var words []string
+	for word := range dictWords(dict) {
+		length := utf8.RuneCountInString(word)
+		if length >= dailyMinLength && length <= dailyMaxLength {
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+
+	rng := rand.New(rand.NewSource(dailySeed(date, wordListID)))
+	order := rng.Perm(len(words))
+	for attempt := 0; attempt < dailyAttempts && attempt < len(order); attempt++ {
+		runes := []rune(words[order[attempt]])
+		offset := rng.Intn(len(runes) - dailyFragmentLength + 1)
+		seed := string(runes[offset : offset+dailyFragmentLength])
+
+		targets := dailyTargets(dict, words, seed)
+		if len(targets) < dailyMinTargets {
+			continue
+		}
+		rng.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+		if len(targets) > dailyMaxTargets {
+			targets = targets[:dailyMaxTargets]
+		}
+		sort.Strings(targets)
+		return DailyPuzzle{Date: date, WordListID: wordListID, Seed: seed, Targets: targets}, nil
+	}
+	return DailyPuzzle{}, fmt.Errorf("no daily puzzle could be generated for %s", date)
+}
+
+// dailyTargets lists the words that can be built from seed, wherever it
+// occurs in them
+func dailyTargets(dict WordDictionaryI, words []string, seed string) []string {
+	n := utf8.RuneCountInString(seed)
+	var targets []string
+	for _, word := range words {
+		if !strings.Contains(word, seed) {
+			continue
+		}
+		runes := []rune(word)
+		solver := newPuzzleSolver(dict, word)
+		for i := 0; i+n <= len(runes); i++ {
+			if string(runes[i:i+n]) == seed && solver.reachable(i, i+n) {
+				targets = append(targets, word)
+				break
+			}
+		}
+	}
+	return targets
+}
+
+// StartDailyPuzzle turns a fresh state into a session on the daily puzzle,
+// starting from its seed fragment
+func StartDailyPuzzle(state WordBuilderState, dict WordDictionaryI, puzzle DailyPuzzle) WordBuilderState {
+	state.Daily = &DailyState{Date: puzzle.Date, Seed: puzzle.Seed, Targets: puzzle.Targets, Found: []string{}}
+	state.Answer = puzzle.Seed
+	state.IsValidWord = CheckValidWord(state, dict)
+	return UpdateSets(state, dict)
+}
+
+// RecordDailyTarget adds the answer to the targets found if it is one of the
+// day's targets, and returns a message when it is
+func RecordDailyTarget(state WordBuilderState) (WordBuilderState, string) {
+	if state.Daily == nil || !state.IsValidWord || !slices.Contains(state.Daily.Targets, state.Answer) || slices.Contains(state.Daily.Found, state.Answer) {
+		return state, ""
+	}
+
+	daily := *state.Daily
+	daily.Found = append(append(make([]string, 0, len(daily.Found)+1), daily.Found...), state.Answer)
+	state.Daily = &daily
+
+	message := fmt.Sprintf("Found '%s', %d of %d daily words!", state.Answer, len(daily.Found), len(daily.Targets))
+	if daily.Complete() {
+		message += " Puzzle complete!"
+	}
+	return state, message
+}
+
+// Complete reports whether every target has been found
+func (d *DailyState) Complete() bool {
+	return len(d.Found) == len(d.Targets)
+}
+
+// GetDailyView returns the parts of the daily puzzle the player may see
+func GetDailyView(daily *DailyState) map[string]interface{} {
+	if daily == nil {
+		return nil
+	}
+	found := daily.Found
+	if found == nil {
+		found = []string{}
+	}
+	return map[string]interface{}{
+		"date":         daily.Date,
+		"seed":         daily.Seed,
+		"target_count": len(daily.Targets),
+		"found":        found,
+		"complete":     daily.Complete(),
+	}
+}
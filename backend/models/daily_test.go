@@ -0,0 +1,72 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dailyWords = []string{"cart", "care", "card", "cars", "scar", "cat", "carton", "scare", "oscar"}
+
+func TestGenerateDailyPuzzle(t *testing.T) {
+	dict := NewWordDictionary(dailyWords)
+	puzzle, err := GenerateDailyPuzzle(dict, "2024-03-01", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(puzzle.Targets) < dailyMinTargets || len(puzzle.Targets) > dailyMaxTargets {
+		t.Errorf("Expected %d to %d targets, got %v", dailyMinTargets, dailyMaxTargets, puzzle.Targets)
+	}
+	for _, target := range puzzle.Targets {
+		if !strings.Contains(target, puzzle.Seed) || !dict.ContainsWord(target) {
+			t.Errorf("Target %q doesn't contain the seed %q", target, puzzle.Seed)
+		}
+	}
+
+	// The same day and list give the same puzzle, whatever order the words were loaded in
+	reversed := make([]string, len(dailyWords))
+	for i, word := range dailyWords {
+		reversed[len(dailyWords)-1-i] = word
+	}
+	again, _ := GenerateDailyPuzzle(NewWordDictionary(reversed), "2024-03-01", 1)
+	if !reflect.DeepEqual(puzzle, again) {
+		t.Errorf("Expected the same puzzle, got %+v and %+v", puzzle, again)
+	}
+
+	if _, err := GenerateDailyPuzzle(dict, "yesterday", 1); err == nil {
+		t.Error("Expected an error for an invalid date")
+	}
+	if _, err := GenerateDailyPuzzle(NewWordDictionary([]string{"cat"}), "2024-03-01", 1); err == nil {
+		t.Error("Expected an error when no puzzle can be generated")
+	}
+}
+
+func TestRecordDailyTarget(t *testing.T) {
+	dict := NewWordDictionary(dailyWords)
+	state := StartDailyPuzzle(UpdateSets(WordBuilderState{}, dict), dict, DailyPuzzle{Date: "2024-03-01", Seed: "ca", Targets: []string{"cart", "scar"}})
+	if state.Answer != "ca" {
+		t.Fatalf("Expected to start from the seed, got %q", state.Answer)
+	}
+
+	state, _, _ = AddLetter(state, dict, "r", "suffix")
+	state, _, _ = AddLetter(state, dict, "t", "suffix")
+	state, message := RecordDailyTarget(state)
+	if !equalStringSlices(state.Daily.Found, []string{"cart"}) || message == "" {
+		t.Fatalf("Expected 'cart' found, got %v", state.Daily.Found)
+	}
+	if state, message = RecordDailyTarget(state); len(state.Daily.Found) != 1 || message != "" {
+		t.Error("Expected 'cart' to be found only once")
+	}
+
+	// Valid words that aren't targets don't count
+	state.Answer = "cars"
+	if state, _ = RecordDailyTarget(state); len(state.Daily.Found) != 1 {
+		t.Error("Expected only targets to count")
+	}
+
+	state.Answer = "scar"
+	state, message = RecordDailyTarget(state)
+	if !state.Daily.Complete() || !strings.Contains(message, "complete") {
+		t.Errorf("Expected the puzzle complete, got %v: %q", state.Daily.Found, message)
+	}
+}
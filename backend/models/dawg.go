@@ -0,0 +1,254 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+)
+
+// DAWGNode is a state of a DAWG. Its edges are sorted by letter and may be
+// shared with other nodes.
+type DAWGNode struct {
+	Edges  []DAWGEdge
+	IsWord bool
+	id     int32 // Position in the register while building
+	words  int32 // Words ending at the node or below, for numbering them
+}
+
+// DAWGEdge leads from a node to the next on a letter
+type DAWGEdge struct {
+	Letter rune
+	Node   *DAWGNode
+}
+
+// DAWG is a directed acyclic word graph: a trie whose identical subtrees are
+// merged, so words sharing an ending share its nodes. It holds the same
+// words as a Trie in far fewer nodes, but can't be added to once built.
+type DAWG struct {
+	Root  *DAWGNode
+	nodes int // Distinct nodes, for comparing with a trie
+}
+
+// dawgBuilder minimizes the trie as the words are added in sorted order,
+// following Daciuk et al. Nodes are only checked against the register once
+// no later word can add to them.
+type dawgBuilder struct {
+	root      *DAWGNode
+	register  map[string]*DAWGNode
+	unchecked []uncheckedEdge
+	previous  []rune
+}
+
+// uncheckedEdge is an edge whose node may still be merged with an equal one
+type uncheckedEdge struct {
+	parent *DAWGNode
+	letter rune
+	child  *DAWGNode
+}
+
+// NewDAWG builds a DAWG holding words, in any order and with duplicates
+func NewDAWG(words []string) *DAWG {
+	sorted := append([]string(nil), words...)
+	sort.Strings(sorted)
+
+	b := &dawgBuilder{root: &DAWGNode{}, register: make(map[string]*DAWGNode)}
+	for i, word := range sorted {
+		if i > 0 && word == sorted[i-1] {
+			continue
+		}
+		b.add([]rune(word))
+	}
+	b.minimize(0)
+	b.root.countWords()
+	return &DAWG{Root: b.root, nodes: len(b.register) + 1}
+}
+
+// add inserts a word that sorts after every word added before
+func (b *dawgBuilder) add(word []rune) {
+	common := 0
+	for common < len(word) && common < len(b.previous) && word[common] == b.previous[common] {
+		common++
+	}
+	b.minimize(common)
+
+	node := b.root
+	if len(b.unchecked) > 0 {
+		node = b.unchecked[len(b.unchecked)-1].child
+	}
+	for _, ch := range word[common:] {
+		next := &DAWGNode{}
+		node.Edges = append(node.Edges, DAWGEdge{Letter: ch, Node: next})
+		b.unchecked = append(b.unchecked, uncheckedEdge{parent: node, letter: ch, child: next})
+		node = next
+	}
+	node.IsWord = true
+	b.previous = word
+}
+
+// minimize merges the unchecked nodes deeper than depth into equal
+// registered nodes, or registers them
+func (b *dawgBuilder) minimize(depth int) {
+	for i := len(b.unchecked) - 1; i >= depth; i-- {
+		edge := b.unchecked[i]
+		key := edge.child.signature()
+		if existing, ok := b.register[key]; ok {
+			edge.parent.Edges[len(edge.parent.Edges)-1].Node = existing
+		} else {
+			edge.child.id = int32(len(b.register))
+			edge.child.countWords()
+			b.register[key] = edge.child
+		}
+	}
+	b.unchecked = b.unchecked[:depth]
+}
+
+// signature identifies a node by whether it ends a word and where its edges
+// lead. Its children are registered already, so their ids tell them apart.
+func (n *DAWGNode) signature() string {
+	key := make([]byte, 0, 1+len(n.Edges)*8)
+	if n.IsWord {
+		key = append(key, '1')
+	} else {
+		key = append(key, '0')
+	}
+	for _, edge := range n.Edges {
+		key = append(key, '|')
+		key = strconv.AppendInt(key, int64(edge.Letter), 36)
+		key = append(key, ':')
+		key = strconv.AppendInt(key, int64(edge.Node.id), 36)
+	}
+	return string(key)
+}
+
+// countWords counts the words ending at or below the node, once its
+// children are counted
+func (n *DAWGNode) countWords() {
+	n.words = 0
+	if n.IsWord {
+		n.words = 1
+	}
+	for _, edge := range n.Edges {
+		n.words += edge.Node.words
+	}
+}
+
+// next follows the edge of a letter, if the node has one
+func (n *DAWGNode) next(ch rune) *DAWGNode {
+	i := sort.Search(len(n.Edges), func(i int) bool { return n.Edges[i].Letter >= ch })
+	if i < len(n.Edges) && n.Edges[i].Letter == ch {
+		return n.Edges[i].Node
+	}
+	return nil
+}
+
+// walk follows a prefix from the root, returning nil if no word starts with it
+func (d *DAWG) walk(prefix string) *DAWGNode {
+	node := d.Root
+	for _, ch := range prefix {
+		if node = node.next(ch); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// NodeCount returns the number of distinct nodes in the graph
+func (d *DAWG) NodeCount() int {
+	return d.nodes
+}
+
+// WordCount returns the number of distinct words in the graph
+func (d *DAWG) WordCount() int {
+	return int(d.Root.words)
+}
+
+// wordIndex returns the position of a word among the words of the DAWG in
+// sorted order, counting the words in the branches it passes on the way.
+// Shared nodes can't hold anything about a single word, so this numbers the
+// words for looking up what is kept aside for them.
+func (d *DAWG) wordIndex(word string) (int, bool) {
+	node, index := d.Root, 0
+	for _, ch := range word {
+		if node.IsWord {
+			index++
+		}
+		i := sort.Search(len(node.Edges), func(i int) bool { return node.Edges[i].Letter >= ch })
+		if i == len(node.Edges) || node.Edges[i].Letter != ch {
+			return -1, false
+		}
+		for _, edge := range node.Edges[:i] {
+			index += int(edge.Node.words)
+		}
+		node = node.Edges[i].Node
+	}
+	if !node.IsWord {
+		return -1, false
+	}
+	return index, true
+}
+
+// Contains checks if a word exists in the DAWG
+func (d *DAWG) Contains(word string) bool {
+	node := d.walk(word)
+	return node != nil && node.IsWord
+}
+
+// KeysWithPrefix returns all words that start with the given prefix, sorted
+func (d *DAWG) KeysWithPrefix(prefix string) []string {
+	return keysWithPrefix(d.Root, prefix, "", 0)
+}
+
+// KeysWithPrefixAfter returns up to limit words that start with the given
+// prefix and sort after cursor
+func (d *DAWG) KeysWithPrefixAfter(prefix, cursor string, limit int) []string {
+	return keysWithPrefix(d.Root, prefix, cursor, limit)
+}
+
+// GetNextLetters returns the possible next letters after a given prefix
+func (d *DAWG) GetNextLetters(prefix string) []string {
+	node := d.walk(prefix)
+	if node == nil {
+		return []string{}
+	}
+	letters := make([]string, 0, len(node.Edges))
+	for _, edge := range node.Edges {
+		letters = append(letters, string(edge.Letter))
+	}
+	return letters
+}
+
+// MatchPattern returns the words matching a wildcard pattern, sorted
+func (d *DAWG) MatchPattern(pattern string) ([]string, error) {
+	return matchPattern(d.Root, pattern, "", 0)
+}
+
+// MatchPatternAfter returns up to limit words matching a wildcard pattern
+// that sort after cursor
+func (d *DAWG) MatchPatternAfter(pattern, cursor string, limit int) ([]string, error) {
+	return matchPattern(d.Root, pattern, cursor, limit)
+}
+
+// WithinDistance returns the words within maxDistance edits of word
+func (d *DAWG) WithinDistance(word string, maxDistance int) []SimilarWord {
+	return withinDistance(d.Root, word, maxDistance)
+}
+
+func (d *DAWG) root() graphNode {
+	return d.Root
+}
+
+func (n *DAWGNode) isWord() bool {
+	return n.IsWord
+}
+
+func (n *DAWGNode) child(ch rune) (graphNode, bool) {
+	if next := n.next(ch); next != nil {
+		return next, true
+	}
+	return nil, false
+}
+
+func (n *DAWGNode) eachChild(fn func(ch rune, child graphNode)) {
+	for _, edge := range n.Edges {
+		fn(edge.Letter, edge.Node)
+	}
+}
@@ -0,0 +1,140 @@
+package models
+
+import "testing"
+
+var dawgWords = []string{"tap", "taps", "top", "tops", "stop", "stops", "cat", "cats", "tap", "café"}
+
+func TestDAWGMatchesTrie(t *testing.T) {
+	dawg := NewDAWG(dawgWords)
+	trie := NewTrie()
+	for _, word := range dawgWords {
+		trie.Insert(word)
+	}
+
+	for _, word := range []string{"tap", "taps", "stops", "café", "ta", "stopss", "dog", ""} {
+		if dawg.Contains(word) != trie.Contains(word) {
+			t.Errorf("Contains(%q) = %v; the trie says %v", word, dawg.Contains(word), trie.Contains(word))
+		}
+	}
+
+	for _, prefix := range []string{"", "t", "to", "sto", "caf", "x"} {
+		got, want := dawg.KeysWithPrefix(prefix), trie.KeysWithPrefix(prefix)
+		if !equalStringSlices(got, want) {
+			t.Errorf("KeysWithPrefix(%q) = %v; want %v", prefix, got, want)
+		}
+
+		got, want = dawg.GetNextLetters(prefix), trie.GetNextLetters(prefix)
+		if !equalStringSlices(got, want) {
+			t.Errorf("GetNextLetters(%q) = %v; want %v", prefix, got, want)
+		}
+	}
+
+	for _, pattern := range []string{"t?p*", "*s", "*o*", "[cs]*"} {
+		got, _ := dawg.MatchPattern(pattern)
+		want, _ := trie.MatchPattern(pattern)
+		if !equalStringSlices(got, want) {
+			t.Errorf("MatchPattern(%q) = %v; want %v", pattern, got, want)
+		}
+	}
+
+	if got, want := len(dawg.WithinDistance("tip", 1)), len(trie.WithinDistance("tip", 1)); got != want {
+		t.Errorf("Expected %d words within one edit of 'tip', got %d", want, got)
+	}
+}
+
+func TestDAWGSharesSuffixes(t *testing.T) {
+	dawg := NewDAWG(dawgWords)
+
+	// "taps", "tops" and "stops" all end on the same "ps" nodes
+	if dawg.Root.next('t').next('a') == nil {
+		t.Fatal("Expected a path for 'ta'")
+	}
+	if dawg.Root.next('t').next('a') != dawg.Root.next('t').next('o') {
+		t.Error("Expected 'ta' and 'to' to lead to the same node")
+	}
+
+	trieNodes := 0
+	var count func(node *TrieNode)
+	count = func(node *TrieNode) {
+		trieNodes++
+		for _, child := range node.Children {
+			count(child)
+		}
+	}
+	trie := NewTrie()
+	for _, word := range dawgWords {
+		trie.Insert(word)
+	}
+	count(trie.Root)
+	if dawg.NodeCount() >= trieNodes {
+		t.Errorf("Expected fewer than %d nodes, got %d", trieNodes, dawg.NodeCount())
+	}
+}
+
+func TestDAWGNumbersWords(t *testing.T) {
+	dawg := NewDAWG(dawgWords)
+	words := dawg.KeysWithPrefix("")
+	if dawg.WordCount() != len(words) {
+		t.Errorf("WordCount() = %d, want %d", dawg.WordCount(), len(words))
+	}
+	for want, word := range words {
+		if got, ok := dawg.wordIndex(word); got != want || !ok {
+			t.Errorf("wordIndex(%q) = %d, %v; want %d", word, got, ok, want)
+		}
+	}
+	for _, word := range []string{"", "ta", "stopss", "dog"} {
+		if _, ok := dawg.wordIndex(word); ok {
+			t.Errorf("Expected no index for %q", word)
+		}
+	}
+}
+
+func TestCompactDictionary(t *testing.T) {
+	dict := NewWordDictionaryWithOptions(sampleWords, DictionaryOptions{Compact: true})
+	if dict.WordSet != nil || dict.WordList != nil {
+		t.Error("Expected a compact dictionary to drop the word set and the word list")
+	}
+	if got := dict.GetWordList(); !equalStringSlices(got, []string{"apple", "banana", "band", "bandana", "cab", "can"}) || dict.WordCount() != len(got) {
+		t.Errorf("GetWordList() = %v, WordCount() = %d", got, dict.WordCount())
+	}
+	for _, word := range sampleWords {
+		if !dict.ContainsWord(word) {
+			t.Errorf("Expected ContainsWord(%q) to be true", word)
+		}
+	}
+	if dict.ContainsWord("ban") {
+		t.Error("Expected ContainsWord(\"ban\") to be false")
+	}
+
+	// Ordered by the reversed words
+	if got := dict.FindWordsWithSuffix("ana"); !equalStringSlices(got, []string{"bandana", "banana"}) {
+		t.Errorf("FindWordsWithSuffix(\"ana\") = %v", got)
+	}
+	state := UpdateSets(WordBuilderState{Answer: "ban"}, dict)
+	if !state.SuffixSet["a"] || !state.SuffixSet["d"] {
+		t.Errorf("Unexpected suffix set %v", state.SuffixSet)
+	}
+	if matches := dict.SubAnagrams([]rune("dnab"), 0, 2); len(matches) != 1 || matches[0].Word != "band" {
+		t.Errorf("Expected 'band', got %+v", matches)
+	}
+	if got := dict.Anagrams("NAC"); !equalStringSlices(got, []string{"can"}) {
+		t.Errorf("Anagrams(\"NAC\") = %v", got)
+	}
+	if got := dict.GetReverseSubstringIndex().GetNextLetters("ad"); !equalStringSlices(got, []string{"n"}) {
+		t.Errorf("Reverse GetNextLetters(\"ad\") = %v", got)
+	}
+}
+
+func TestDictWordsStopsEarly(t *testing.T) {
+	dict := NewWordDictionaryWithOptions(sampleWords, DictionaryOptions{Compact: true})
+	var got []string
+	for word := range dictWords(dict) {
+		got = append(got, word)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if !equalStringSlices(got, []string{"apple", "banana", "band"}) {
+		t.Errorf("dictWords() = %v", got)
+	}
+}
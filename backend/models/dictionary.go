@@ -1,49 +1,197 @@
 package models
 
 import (
+	"sort"
 	"strings"
+	"sync"
+	"unicode/utf8"
+
 	utils "wordbuilder/utils"
 )
 
 // WordDictionary holds both forward and reverse tries for efficient lookups
 type WordDictionary struct {
-	WordSet     map[string]bool // For quick word validation
-	ForwardTrie *Trie           // For suffix lookups
-	ReverseTrie *Trie           // For prefix lookups
-	WordList    []string        // Add this field
+	WordSet     map[string]bool // For quick word validation, nil in a compact dictionary
+	ForwardTrie WordGraph       // For suffix lookups
+	ReverseTrie WordGraph       // For prefix lookups
+	WordList    []string        // In the order of the word list, nil in a compact dictionary
+	Alphabet    []string        // Letters used by the words, sorted
+
+	// How common the words are, when the word list says. Tries keep the
+	// frequencies as the weights of their words, but the nodes of a DAWG are
+	// shared between words, so a compact dictionary keeps them aside by the
+	// position of each word in the DAWG.
+	weighted    bool
+	frequencies []float64 // Only set in a compact dictionary
+
+	// Substring indexes are built on first use, as only the game needs them
+	substringOnce         sync.Once
+	substringIndex        *SubstringIndex // Substrings of every word
+	reverseSubstringIndex *SubstringIndex // Substrings of every reversed word
+}
+
+// DictionaryOptions selects how a dictionary holds its words
+type DictionaryOptions struct {
+	// Compact keeps the words in DAWGs rather than tries and drops the word
+	// set and the word list, taking a fraction of the memory for slightly
+	// slower lookups
+	Compact bool
 }
 
 // NewWordDictionary creates a new dictionary with both tries
 func NewWordDictionary(wordList []string) *WordDictionary {
-	dict := &WordDictionary{
-		WordSet:     make(map[string]bool),
-		ForwardTrie: NewTrie(),
-		ReverseTrie: NewTrie(),
-		WordList:    make([]string, 0, len(wordList)),
-	}
+	return NewWordDictionaryWithOptions(wordList, DictionaryOptions{})
+}
+
+// NewWordDictionaryWithOptions creates a new dictionary, holding its words
+// as the options say
+func NewWordDictionaryWithOptions(wordList []string, opts DictionaryOptions) *WordDictionary {
+	return NewWeightedWordDictionary(wordList, nil, opts)
+}
 
+// NewWeightedWordDictionary creates a new dictionary whose words come with
+// how common they are, from 0 for the rarest to 1 for the commonest, as
+// NormalizeWeightedWords reads them. Without frequencies, every word is as
+// common as the others.
+func NewWeightedWordDictionary(wordList []string, frequencies map[string]float64, opts DictionaryOptions) *WordDictionary {
+	words := make([]string, 0, len(wordList))
 	for _, word := range wordList {
-		word = strings.ToLower(word)
-		dict.WordSet[word] = true
-		dict.ForwardTrie.Insert(word)
-		dict.ReverseTrie.Insert(utils.ReverseString(word))
-		dict.WordList = append(dict.WordList, word) // Populate WordList
+		words = append(words, strings.ToLower(word))
+	}
+	dict := &WordDictionary{Alphabet: AlphabetOf(words)}
+
+	reversed := make([]string, len(words))
+	for i, word := range words {
+		reversed[i] = utils.ReverseString(word)
+	}
+
+	if opts.Compact {
+		// The DAWG spells the words out again when they're needed
+		dict.ForwardTrie = NewDAWG(words)
+		dict.ReverseTrie = NewDAWG(reversed)
+		dict.setFrequencies(words, frequencies)
+		return dict
 	}
 
+	forward, reverse := NewTrie(), NewTrie()
+	dict.WordList = words
+	dict.WordSet = make(map[string]bool, len(words))
+	for i, word := range words {
+		dict.WordSet[word] = true
+		forward.Insert(word)
+		reverse.Insert(reversed[i])
+	}
+	dict.ForwardTrie, dict.ReverseTrie = forward, reverse
+	dict.setFrequencies(words, frequencies)
 	return dict
 }
 
+// setFrequencies records how common the words are, 0 for the words missing
+// from frequencies
+func (d *WordDictionary) setFrequencies(words []string, frequencies map[string]float64) {
+	if frequencies == nil {
+		return
+	}
+	d.weighted = true
+	if dawg, compact := d.ForwardTrie.(*DAWG); compact {
+		d.frequencies = make([]float64, dawg.WordCount())
+		for _, word := range words {
+			if i, ok := dawg.wordIndex(word); ok {
+				d.frequencies[i] = frequencies[word]
+			}
+		}
+		return
+	}
+	for _, word := range words {
+		frequency := frequencies[word]
+		d.ForwardTrie.(*Trie).InsertWeighted(word, frequency)
+		d.ReverseTrie.(*Trie).InsertWeighted(utils.ReverseString(word), frequency)
+	}
+}
+
+// HasFrequencies reports whether the dictionary knows how common its words are
+func (d *WordDictionary) HasFrequencies() bool {
+	return d.weighted
+}
+
+// WordFrequency returns how common a word is, from 0 for the rarest to 1 for
+// the commonest, if the dictionary knows
+func (d *WordDictionary) WordFrequency(word string) (float64, bool) {
+	if !d.weighted {
+		return 0, false
+	}
+	switch graph := d.ForwardTrie.(type) {
+	case *Trie:
+		return graph.Weight(word)
+	case *DAWG:
+		if i, ok := graph.wordIndex(word); ok {
+			return d.frequencies[i], true
+		}
+	}
+	return 0, false
+}
+
+// RankedWordsWithPrefix returns up to limit words starting with prefix, the
+// commonest first, or in alphabetical order when the dictionary doesn't know
+// how common they are. A limit of 0 returns every word.
+func (d *WordDictionary) RankedWordsWithPrefix(prefix string, limit int) []string {
+	return d.rankedKeys(d.ForwardTrie, prefix, limit, false)
+}
+
+// RankedWordsWithSuffix returns up to limit words ending with suffix, the
+// commonest first, or in the order of their reversed spelling when the
+// dictionary doesn't know how common they are
+func (d *WordDictionary) RankedWordsWithSuffix(suffix string, limit int) []string {
+	keys := d.rankedKeys(d.ReverseTrie, utils.ReverseString(suffix), limit, true)
+	for i, key := range keys {
+		keys[i] = utils.ReverseString(key)
+	}
+	return keys
+}
+
+// rankedKeys lists the keys of a graph starting with prefix, the commonest
+// words first. reversed tells the keys are spelled backward.
+func (d *WordDictionary) rankedKeys(graph WordGraph, prefix string, limit int, reversed bool) []string {
+	if !d.weighted {
+		return graph.KeysWithPrefixAfter(prefix, "", limit)
+	}
+	if trie, ok := graph.(*Trie); ok {
+		return trie.TopKeysWithPrefix(prefix, limit)
+	}
+
+	// Without weights in the graph, every key has to be ranked
+	keys := graph.KeysWithPrefix(prefix)
+	frequency := func(key string) float64 {
+		if reversed {
+			key = utils.ReverseString(key)
+		}
+		frequency, _ := d.WordFrequency(key)
+		return frequency
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return frequency(keys[i]) > frequency(keys[j])
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 // ContainsWord checks if a word exists in the dictionary
 func (d *WordDictionary) ContainsWord(word string) bool {
+	if d.WordSet == nil {
+		return d.ForwardTrie.Contains(word)
+	}
 	return d.WordSet[word]
 }
 
-// FindWordsWithPrefix returns all words starting with the given prefix
+// FindWordsWithPrefix returns all words starting with the given prefix, sorted
 func (d *WordDictionary) FindWordsWithPrefix(prefix string) []string {
 	return d.ForwardTrie.KeysWithPrefix(prefix)
 }
 
-// FindWordsWithSuffix returns all words ending with the given suffix
+// FindWordsWithSuffix returns all words ending with the given suffix, sorted
+// by their reversed spelling
 func (d *WordDictionary) FindWordsWithSuffix(suffix string) []string {
 	reversed := utils.ReverseString(suffix)
 	reversedWords := d.ReverseTrie.KeysWithPrefix(reversed)
@@ -57,12 +205,98 @@ func (d *WordDictionary) FindWordsWithSuffix(suffix string) []string {
 	return result
 }
 
+// FindWordsMatching returns the words matching a wildcard pattern, sorted
+func (d *WordDictionary) FindWordsMatching(pattern string) ([]string, error) {
+	return d.ForwardTrie.MatchPattern(pattern)
+}
+
+// FindWordsMatchingAfter returns up to limit words matching a wildcard
+// pattern that sort after cursor, for reading the matches a page at a time
+func (d *WordDictionary) FindWordsMatchingAfter(pattern, cursor string, limit int) ([]string, error) {
+	return d.ForwardTrie.MatchPatternAfter(pattern, cursor, limit)
+}
+
 func (d *WordDictionary) GetForwardTrie() TrieI {
 	return d.ForwardTrie
 }
 func (d *WordDictionary) GetReverseTrie() TrieI {
 	return d.ReverseTrie
 }
+
+// GetWordList returns the words of the dictionary. A compact dictionary
+// doesn't keep the list and spells it out of its DAWG, in sorted order.
 func (d *WordDictionary) GetWordList() []string {
+	if d.WordList == nil {
+		return d.ForwardTrie.KeysWithPrefix("")
+	}
 	return d.WordList
 }
+
+// FirstWords returns up to limit words in the order of the word list, or
+// in sorted order in a compact dictionary
+func (d *WordDictionary) FirstWords(limit int) []string {
+	if d.WordList == nil {
+		return d.ForwardTrie.KeysWithPrefixAfter("", "", limit)
+	}
+	return append([]string{}, d.WordList[:min(limit, len(d.WordList))]...)
+}
+
+// WordCount returns the number of words in the dictionary
+func (d *WordDictionary) WordCount() int {
+	if dawg, compact := d.ForwardTrie.(*DAWG); compact {
+		return dawg.WordCount()
+	}
+	return len(d.WordList)
+}
+
+// GetAlphabet returns the letters used by the words of the dictionary
+func (d *WordDictionary) GetAlphabet() []string {
+	return d.Alphabet
+}
+
+// AlphabetOf collects the distinct letters of words, sorted
+func AlphabetOf(words []string) []string {
+	seen := make(map[rune]bool)
+	alphabet := []string{}
+	for _, word := range words {
+		for _, ch := range word {
+			if !seen[ch] {
+				seen[ch] = true
+				alphabet = append(alphabet, string(ch))
+			}
+		}
+	}
+	sort.Strings(alphabet)
+	return alphabet
+}
+
+// IsLetter reports whether letter is a single letter of the dictionary's alphabet
+func IsLetter(dict WordDictionaryI, letter string) bool {
+	if utf8.RuneCountInString(letter) != 1 {
+		return false
+	}
+	alphabet := dict.GetAlphabet()
+	i := sort.SearchStrings(alphabet, letter)
+	return i < len(alphabet) && alphabet[i] == letter
+}
+
+// GetSubstringIndex returns the index of substrings for letters that can follow a fragment
+func (d *WordDictionary) GetSubstringIndex() SubstringIndexI {
+	d.buildSubstringIndexes()
+	return d.substringIndex
+}
+
+// GetReverseSubstringIndex returns the index of reversed substrings for letters that can precede a fragment
+func (d *WordDictionary) GetReverseSubstringIndex() SubstringIndexI {
+	d.buildSubstringIndexes()
+	return d.reverseSubstringIndex
+}
+
+// buildSubstringIndexes builds both substring indexes once, walking the
+// forward and reverse graphs rather than spelling out the word list
+func (d *WordDictionary) buildSubstringIndexes() {
+	d.substringOnce.Do(func() {
+		d.substringIndex = newSubstringIndex(graphWords(d.ForwardTrie.root()))
+		d.reverseSubstringIndex = newSubstringIndex(graphWords(d.ReverseTrie.root()))
+	})
+}
@@ -52,8 +52,6 @@ func TestFindWordsWithPrefix(t *testing.T) {
 	}
 	for _, tt := range tests {
 		results := dict.FindWordsWithPrefix(tt.prefix)
-		sort.Strings(results)
-		sort.Strings(tt.expected)
 		if !equalStringSlices(results, tt.expected) {
 			t.Errorf("FindWordsWithPrefix(%q) = %v; want %v", tt.prefix, results, tt.expected)
 		}
@@ -62,11 +60,12 @@ func TestFindWordsWithPrefix(t *testing.T) {
 
 func TestFindWordsWithSuffix(t *testing.T) {
 	dict := newTestDictionary()
+	// Words come in the order of their reversed spelling
 	tests := []struct {
 		suffix   string
 		expected []string
 	}{
-		{"ana", []string{"banana", "bandana"}},
+		{"ana", []string{"bandana", "banana"}},
 		{"le", []string{"apple"}},
 		{"d", []string{"band"}},
 		{"n", []string{"can"}},
@@ -74,8 +73,6 @@ func TestFindWordsWithSuffix(t *testing.T) {
 	}
 	for _, tt := range tests {
 		results := dict.FindWordsWithSuffix(tt.suffix)
-		sort.Strings(results)
-		sort.Strings(tt.expected)
 		if !equalStringSlices(results, tt.expected) {
 			t.Errorf("FindWordsWithSuffix(%q) = %v; want %v", tt.suffix, results, tt.expected)
 		}
@@ -97,3 +94,58 @@ func TestGetForwardTrie_ReverseTrie_WordList(t *testing.T) {
 		t.Errorf("GetWordList() = %v; want %v", wordList, sampleWords)
 	}
 }
+
+func TestGetAlphabet(t *testing.T) {
+	dict := NewWordDictionary([]string{"Straße", "año", "việt"})
+	want := []string{"a", "e", "o", "r", "s", "t", "v", "ß", "ñ", "ệ", "i"}
+	sort.Strings(want)
+	if got := dict.GetAlphabet(); !equalStringSlices(got, want) {
+		t.Errorf("GetAlphabet() = %v, want %v", got, want)
+	}
+
+	for letter, want := range map[string]bool{"ñ": true, "ß": true, "a": true, "x": false, "ñn": false, "": false} {
+		if got := IsLetter(dict, letter); got != want {
+			t.Errorf("IsLetter(%q) = %v, want %v", letter, got, want)
+		}
+	}
+}
+
+func TestWordFrequencies(t *testing.T) {
+	frequencies := map[string]float64{"apple": 0.9, "banana": 0.4, "band": 0.8, "cab": 0.1}
+	for _, opts := range []DictionaryOptions{{}, {Compact: true}} {
+		dict := NewWeightedWordDictionary(sampleWords, frequencies, opts)
+		if !dict.HasFrequencies() {
+			t.Fatal("Expected the dictionary to have frequencies")
+		}
+		if frequency, ok := dict.WordFrequency("band"); frequency != 0.8 || !ok {
+			t.Errorf("WordFrequency(\"band\") = %v, %v", frequency, ok)
+		}
+		if frequency, ok := dict.WordFrequency("can"); frequency != 0 || !ok {
+			t.Errorf("Expected words without a frequency to be the rarest, got %v, %v", frequency, ok)
+		}
+		if _, ok := dict.WordFrequency("dog"); ok {
+			t.Error("Expected no frequency for a missing word")
+		}
+
+		if got := dict.RankedWordsWithPrefix("ban", 2); !equalStringSlices(got, []string{"band", "banana"}) {
+			t.Errorf("RankedWordsWithPrefix(\"ban\", 2) = %v (compact %v)", got, opts.Compact)
+		}
+		if got := dict.RankedWordsWithSuffix("n", 0); !equalStringSlices(got, []string{"can"}) {
+			t.Errorf("RankedWordsWithSuffix(\"n\", 0) = %v (compact %v)", got, opts.Compact)
+		}
+
+		// Completions come commonest first rather than shortest first
+		state := UpdateSets(WordBuilderState{Answer: "ban"}, dict)
+		if !equalStringSlices(state.ValidCompletions, []string{"band", "banana", "bandana"}) {
+			t.Errorf("ValidCompletions = %v (compact %v)", state.ValidCompletions, opts.Compact)
+		}
+	}
+
+	dict := newTestDictionary()
+	if _, ok := dict.WordFrequency("apple"); ok || dict.HasFrequencies() {
+		t.Error("Expected no frequencies without a frequency column")
+	}
+	if got := dict.RankedWordsWithPrefix("ban", 2); !equalStringSlices(got, []string{"banana", "band"}) {
+		t.Errorf("Expected alphabetical order without frequencies, got %v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bounds on fuzzy searches, as the words to visit grow quickly with the
+// distance and each letter of the word searched for widens every row
+const (
+	MaxEditDistance   = 3
+	MaxSimilarLetters = 30
+)
+
+// SimilarWord is a dictionary word close to the word searched for
+type SimilarWord struct {
+	Word      string  `json:"word"`
+	Distance  int     `json:"distance"`            // Letters to insert, delete or substitute to get the word
+	Frequency float64 `json:"frequency,omitempty"` // How common the word is, when the dictionary knows
+}
+
+// WithinDistance returns the words of the trie within maxDistance edits of
+// word, with their distance. Each node extends the previous row of the
+// Levenshtein table by one letter, and branches are abandoned as soon as
+// every cell of the row exceeds maxDistance.
+func (t *Trie) WithinDistance(word string, maxDistance int) []SimilarWord {
+	return withinDistance(t.Root, word, maxDistance)
+}
+
+// withinDistance searches the words of a graph within maxDistance edits
+func withinDistance(root graphNode, word string, maxDistance int) []SimilarWord {
+	target := []rune(word)
+	row := make([]int, len(target)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	var results []SimilarWord
+	var walk func(node graphNode, prefix string, previous []int)
+	walk = func(node graphNode, prefix string, previous []int) {
+		if node.isWord() && previous[len(target)] <= maxDistance {
+			results = append(results, SimilarWord{Word: prefix, Distance: previous[len(target)]})
+		}
+		node.eachChild(func(ch rune, child graphNode) {
+			current := make([]int, len(target)+1)
+			current[0] = previous[0] + 1
+			best := current[0]
+			for i := 1; i <= len(target); i++ {
+				cost := 1
+				if target[i-1] == ch {
+					cost = 0
+				}
+				current[i] = min(current[i-1]+1, previous[i]+1, previous[i-1]+cost)
+				best = min(best, current[i])
+			}
+			if best <= maxDistance {
+				walk(child, prefix+string(ch), current)
+			}
+		})
+	}
+	walk(root, "", row)
+	return results
+}
+
+// FindSimilarWords suggests words close to a possibly misspelled word, the
+// closest first, then the most common, then in alphabetical order. The word
+// itself is left out. limit bounds the words returned, 0 for no limit.
+func (d *WordDictionary) FindSimilarWords(word string, maxDistance, limit int) ([]SimilarWord, error) {
+	word = strings.ToLower(word)
+	if count := utf8.RuneCountInString(word); count == 0 || count > MaxSimilarLetters {
+		return nil, fmt.Errorf("word must be between 1 and %d letters long", MaxSimilarLetters)
+	}
+	maxDistance = min(max(maxDistance, 0), MaxEditDistance)
+
+	var similar []SimilarWord
+	for _, match := range d.ForwardTrie.WithinDistance(word, maxDistance) {
+		if match.Distance == 0 {
+			continue
+		}
+		match.Frequency, _ = d.WordFrequency(match.Word)
+		similar = append(similar, match)
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Distance != similar[j].Distance {
+			return similar[i].Distance < similar[j].Distance
+		}
+		if similar[i].Frequency != similar[j].Frequency {
+			return similar[i].Frequency > similar[j].Frequency
+		}
+		return similar[i].Word < similar[j].Word
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrie_WithinDistance(t *testing.T) {
+	trie := NewTrie()
+	for _, word := range []string{"cat", "cart", "act", "coat", "dog"} {
+		trie.Insert(word)
+	}
+
+	distances := make(map[string]int)
+	for _, match := range trie.WithinDistance("cat", 1) {
+		distances[match.Word] = match.Distance
+	}
+	expected := map[string]int{"cat": 0, "cart": 1, "coat": 1}
+	if len(distances) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, distances)
+	}
+	for word, distance := range expected {
+		if distances[word] != distance {
+			t.Errorf("Expected '%s' at distance %d, got %d", word, distance, distances[word])
+		}
+	}
+
+	if matches := trie.WithinDistance("cat", 2); len(matches) != 4 {
+		t.Errorf("Expected 'act' within two edits as well, got %+v", matches)
+	}
+}
+
+func TestFindSimilarWords(t *testing.T) {
+	dict := newTestDictionary()
+
+	similar, _ := dict.FindSimilarWords("BANDS", 2, 0)
+	var words []string
+	for _, match := range similar {
+		words = append(words, match.Word)
+	}
+	if !equalStringSlices(words, []string{"band"}) {
+		t.Errorf("Expected only 'band', got %v", words)
+	}
+
+	similar, _ = dict.FindSimilarWords("cam", 2, 2)
+	if len(similar) != 2 || similar[0].Word != "cab" || similar[1].Word != "can" || similar[0].Distance != 1 {
+		t.Errorf("Expected cab and can first, got %+v", similar)
+	}
+
+	if similar, _ := dict.FindSimilarWords("apple", 1, 0); len(similar) != 0 {
+		t.Errorf("Expected the word itself to be left out, got %+v", similar)
+	}
+
+	if _, err := dict.FindSimilarWords(strings.Repeat("a", MaxSimilarLetters+1), 1, 0); err == nil {
+		t.Error("Expected an error for a word longer than the limit")
+	}
+}
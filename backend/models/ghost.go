@@ -0,0 +1,377 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+
+	utils "wordbuilder/utils"
+)
+
+// Difficulty levels for the computer opponent in a ghost game
+const (
+	GhostRandom  = "random"  // Plays any letter that keeps the fragment inside a word
+	GhostGreedy  = "greedy"  // Avoids completing words and looks one move ahead
+	GhostPerfect = "perfect" // Searches the game tree for a forced win
+)
+
+// GhostMinWordLength is the length from which completing a word loses the round
+const GhostMinWordLength = 4
+
+// ghostSearchBudget bounds the positions the perfect player evaluates per
+// move, so short fragments over large dictionaries stay responsive
+const ghostSearchBudget = 200000
+
+// GhostState tracks a round of Superghost against the computer.
+//
+// The player and the computer take turns adding a letter to either end of
+// the answer, which must stay inside some dictionary word. Whoever completes
+// a word of GhostMinWordLength letters or more loses. Instead of moving, the
+// player may challenge the fragment: the computer then has to name a word
+// containing it, and loses if it can't.
+type GhostState struct {
+	Difficulty string `json:"difficulty"`
+	Winner     string `json:"winner,omitempty"`  // "player" or "computer" once the round is over
+	Reason     string `json:"reason,omitempty"`  // Why the round ended
+	Witness    string `json:"witness,omitempty"` // Word named in answer to a challenge
+	Bluffed    bool   `json:"bluffed"`           // The computer played a letter that leaves no word, never shown to the player
+}
+
+// ghostMove is a letter the next player could add, and the fragment it makes
+type ghostMove struct {
+	letter   string
+	position string
+	fragment string
+}
+
+// StartGhostGame turns a fresh state into a ghost game at the given difficulty
+func StartGhostGame(state WordBuilderState, difficulty string) (WordBuilderState, error) {
+	if difficulty == "" {
+		difficulty = GhostGreedy
+	}
+	if difficulty != GhostRandom && difficulty != GhostGreedy && difficulty != GhostPerfect {
+		return state, fmt.Errorf("invalid difficulty '%s'", difficulty)
+	}
+	state.Ghost = &GhostState{Difficulty: difficulty}
+	return state, nil
+}
+
+// GhostPlayerMove adds the player's letter and lets the computer answer
+func GhostPlayerMove(state WordBuilderState, dict WordDictionaryI, letter, position string) (WordBuilderState, string, error) {
+	if state.Ghost == nil {
+		return state, "This session is not a ghost game.", fmt.Errorf("not a ghost game")
+	}
+	if state.Ghost.Winner != "" {
+		return state, "The round is over. Reset to play again.", fmt.Errorf("round is over")
+	}
+
+	letter = strings.ToLower(letter)
+	if !IsLetter(dict, letter) {
+		return state, fmt.Sprintf("'%s' is not a letter of this word list.", letter), fmt.Errorf("invalid letter")
+	}
+	if position != "prefix" && position != "suffix" {
+		return state, "Invalid position. Use 'prefix' or 'suffix'.", fmt.Errorf("invalid position")
+	}
+
+	// Any letter of the alphabet goes, as checking it against the words
+	// would tell the player which letters are safe and keep them from
+	// bluffing
+	newState := ConstrainToGhost(forceLetter(state, dict, letter, position))
+	message := fmt.Sprintf("Step %d: Added '%s' as %s -> Answer: %s", newState.Step, letter, position, newState.Answer)
+	if isGhostLoss(dict, newState.Answer) {
+		return endGhostGame(newState, "computer", fmt.Sprintf("You completed the word '%s'.", newState.Answer)), message + "\nYou completed a word and lose the round.", nil
+	}
+	// The computer knows every word, so it calls a bluff at once
+	if !dictHasFragment(dict, newState.Answer) {
+		return endGhostGame(newState, "computer", fmt.Sprintf("No word contains '%s'.", newState.Answer)), message + fmt.Sprintf("\nThe computer challenges: no word contains '%s'. You lose the round.", newState.Answer), nil
+	}
+
+	move, ok := chooseGhostMove(dict, newState.Answer, newState.Ghost.Difficulty)
+	if !ok {
+		return endGhostGame(newState, "player", "The computer has no letter left to play."), message + "\nThe computer gives up. You win!", nil
+	}
+
+	bluffed := !dictHasFragment(dict, move.fragment)
+	newState = ConstrainToGhost(forceLetter(newState, dict, move.letter, move.position))
+	newState.Ghost = copyGhost(newState.Ghost)
+	newState.Ghost.Bluffed = bluffed
+	message += fmt.Sprintf("\nComputer added '%s' as %s -> Answer: %s", move.letter, move.position, newState.Answer)
+
+	if isGhostLoss(dict, newState.Answer) {
+		return endGhostGame(newState, "player", fmt.Sprintf("The computer completed the word '%s'.", newState.Answer)), message + "\nThe computer completed a word. You win!", nil
+	}
+	return newState, message, nil
+}
+
+// ConstrainToGhost hides the completions and the letters that can be added,
+// which would tell the player which words contain the fragment, and so
+// whether the computer bluffed
+func ConstrainToGhost(state WordBuilderState) WordBuilderState {
+	state = hideCompletions(state)
+	state.PrefixSet = map[string]bool{}
+	state.SuffixSet = map[string]bool{}
+	return state
+}
+
+// hideCompletions hides the words the answer leads to and the letters that
+// fit between its letters
+func hideCompletions(state WordBuilderState) WordBuilderState {
+	state.ValidCompletions = []string{}
+	state.GapSets = nil
+	return state
+}
+
+// GhostChallenge resolves the player's claim that no word contains the answer
+func GhostChallenge(state WordBuilderState, dict WordDictionaryI) (WordBuilderState, string, error) {
+	if state.Ghost == nil {
+		return state, "This session is not a ghost game.", fmt.Errorf("not a ghost game")
+	}
+	if state.Ghost.Winner != "" {
+		return state, "The round is over. Reset to play again.", fmt.Errorf("round is over")
+	}
+	if state.Answer == "" {
+		return state, "There is nothing to challenge yet.", fmt.Errorf("nothing to challenge")
+	}
+
+	newState := state
+	newState.Step++
+	witness := findWordContaining(dict, state.Answer)
+	if witness == "" {
+		newState = endGhostGame(newState, "player", fmt.Sprintf("No word contains '%s'.", state.Answer))
+		return newState, fmt.Sprintf("Step %d: Challenge succeeded, no word contains '%s'. You win!", newState.Step, state.Answer), nil
+	}
+
+	newState = endGhostGame(newState, "computer", fmt.Sprintf("'%s' contains '%s'.", witness, state.Answer))
+	newState.Ghost.Witness = witness
+	return newState, fmt.Sprintf("Step %d: Challenge failed, '%s' contains '%s'. You lose the round.", newState.Step, witness, state.Answer), nil
+}
+
+// GetGhostView returns the parts of the ghost state the player may see
+func GetGhostView(ghost *GhostState) map[string]interface{} {
+	if ghost == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"difficulty": ghost.Difficulty,
+		"min_length": GhostMinWordLength,
+		"over":       ghost.Winner != "",
+		"winner":     ghost.Winner,
+		"reason":     ghost.Reason,
+		"witness":    ghost.Witness,
+	}
+}
+
+// endGhostGame records the outcome of the round
+func endGhostGame(state WordBuilderState, winner, reason string) WordBuilderState {
+	state.Ghost = copyGhost(state.Ghost)
+	state.Ghost.Winner = winner
+	state.Ghost.Reason = reason
+	return state
+}
+
+// copyGhost copies the ghost state so earlier states sharing it stay intact
+func copyGhost(ghost *GhostState) *GhostState {
+	copied := *ghost
+	return &copied
+}
+
+// chooseGhostMove picks the computer's letter for the fragment. When every
+// honest letter loses, it bluffs with a letter that leaves no word, which the
+// player can only answer by challenging.
+func chooseGhostMove(dict WordDictionaryI, fragment, difficulty string) (ghostMove, bool) {
+	moves := ghostMoves(dict, fragment)
+	var safe []ghostMove
+	for _, move := range moves {
+		if !isGhostLoss(dict, move.fragment) {
+			safe = append(safe, move)
+		}
+	}
+	if len(safe) == 0 {
+		return ghostBluff(dict, fragment)
+	}
+
+	switch difficulty {
+	case GhostRandom:
+		return safe[rand.Intn(len(safe))], true
+	case GhostPerfect:
+		solver := &ghostSolver{dict: dict, memo: make(map[string]bool), budget: ghostSearchBudget}
+		if move, ok := solver.winningMove(safe); ok {
+			return move, true
+		}
+	}
+	return greedyGhostMove(dict, safe), true
+}
+
+// greedyGhostMove prefers letters that leave the opponent without a safe
+// reply, then letters that leave the opponent the fewest safe replies
+func greedyGhostMove(dict WordDictionaryI, safe []ghostMove) ghostMove {
+	best := safe[0]
+	bestReplies := -1
+	for _, move := range safe {
+		replies := 0
+		for _, reply := range ghostMoves(dict, move.fragment) {
+			if !isGhostLoss(dict, reply.fragment) {
+				replies++
+			}
+		}
+		if bestReplies == -1 || replies < bestReplies {
+			best, bestReplies = move, replies
+		}
+	}
+	return best
+}
+
+// ghostBluff picks a letter that doesn't complete a word, even though it
+// leaves a fragment no word contains
+func ghostBluff(dict WordDictionaryI, fragment string) (ghostMove, bool) {
+	alphabet := dict.GetAlphabet()
+	for _, offset := range rand.Perm(len(alphabet)) {
+		letter := alphabet[offset]
+		for _, position := range []string{"suffix", "prefix"} {
+			move := ghostMove{letter: letter, position: position, fragment: fragment + letter}
+			if position == "prefix" {
+				move.fragment = letter + fragment
+			}
+			if !isGhostLoss(dict, move.fragment) {
+				return move, true
+			}
+		}
+	}
+	return ghostMove{}, false
+}
+
+// ghostMoves lists the letters that keep fragment inside some word
+func ghostMoves(dict WordDictionaryI, fragment string) []ghostMove {
+	var moves []ghostMove
+	if index := dict.GetSubstringIndex(); index != nil {
+		for _, letter := range index.GetNextLetters(fragment) {
+			moves = append(moves, ghostMove{letter: letter, position: "suffix", fragment: fragment + letter})
+		}
+	}
+	if index := dict.GetReverseSubstringIndex(); index != nil {
+		for _, letter := range index.GetNextLetters(utils.ReverseString(fragment)) {
+			moves = append(moves, ghostMove{letter: letter, position: "prefix", fragment: letter + fragment})
+		}
+	}
+	return moves
+}
+
+// isGhostLoss reports whether moving to fragment completes a long enough word
+func isGhostLoss(dict WordDictionaryI, fragment string) bool {
+	return utf8.RuneCountInString(fragment) >= GhostMinWordLength && dict.ContainsWord(fragment)
+}
+
+// dictHasFragment reports whether fragment occurs inside some word
+func dictHasFragment(dict WordDictionaryI, fragment string) bool {
+	if dict.ContainsWord(fragment) {
+		return true
+	}
+	return len(ghostMoves(dict, fragment)) > 0
+}
+
+// findWordContaining returns the shortest word containing fragment, the
+// first in alphabetical order on ties, or "". It grows the fragment a letter
+// at a time through the substring indexes, so only substrings of words are
+// visited, shortest first.
+func findWordContaining(dict WordDictionaryI, fragment string) string {
+	if dict.GetSubstringIndex() == nil || dict.GetReverseSubstringIndex() == nil {
+		best := ""
+		for word := range dictWords(dict) {
+			if strings.Contains(word, fragment) && (best == "" || utf8.RuneCountInString(word) < utf8.RuneCountInString(best)) {
+				best = word
+			}
+		}
+		return best
+	}
+
+	level := []string{fragment}
+	seen := map[string]bool{fragment: true}
+	for len(level) > 0 {
+		best := ""
+		var next []string
+		for _, candidate := range level {
+			if dict.ContainsWord(candidate) && (best == "" || candidate < best) {
+				best = candidate
+			}
+			for _, move := range ghostMoves(dict, candidate) {
+				if !seen[move.fragment] {
+					seen[move.fragment] = true
+					next = append(next, move.fragment)
+				}
+			}
+		}
+		if best != "" {
+			return best
+		}
+		level = next
+	}
+	return ""
+}
+
+// forceLetter adds a letter without checking the letter sets, for moves that
+// may be bluffs
+func forceLetter(state WordBuilderState, dict WordDictionaryI, letter, position string) WordBuilderState {
+	newState := state
+	index := 0
+	if position == "prefix" {
+		newState.Answer = letter + state.Answer
+	} else {
+		newState.Answer = state.Answer + letter
+		index = utf8.RuneCountInString(newState.Answer) - 1
+	}
+	newState.IsValidWord = CheckValidWord(newState, dict)
+	newState = UpdateSets(newState, dict)
+	newState.Step++
+	return recordMove(newState, Move{Action: "add", Letter: letter, Position: position, Index: index, Answer: newState.Answer})
+}
+
+// ghostSolver searches the game tree. A position is won for the player to
+// move if some letter leads to a position lost for the opponent.
+type ghostSolver struct {
+	dict   WordDictionaryI
+	memo   map[string]bool
+	budget int
+}
+
+// winningMove returns a move that forces a win, if the search finds one
+func (g *ghostSolver) winningMove(safe []ghostMove) (ghostMove, bool) {
+	for _, move := range safe {
+		opponentWins, ok := g.wins(move.fragment)
+		if !ok {
+			return ghostMove{}, false
+		}
+		if !opponentWins {
+			return move, true
+		}
+	}
+	return ghostMove{}, false
+}
+
+// wins reports whether the player to move at fragment can force a win. ok is
+// false when the search budget ran out first.
+func (g *ghostSolver) wins(fragment string) (win bool, ok bool) {
+	if win, seen := g.memo[fragment]; seen {
+		return win, true
+	}
+	if g.budget <= 0 {
+		return false, false
+	}
+	g.budget--
+
+	for _, move := range ghostMoves(g.dict, fragment) {
+		if isGhostLoss(g.dict, move.fragment) {
+			continue
+		}
+		opponentWins, ok := g.wins(move.fragment)
+		if !ok {
+			return false, false
+		}
+		if !opponentWins {
+			g.memo[fragment] = true
+			return true, true
+		}
+	}
+	g.memo[fragment] = false
+	return false, true
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newGhostGame starts a ghost game on answer over a dictionary where "car"
+// forces the opponent to complete "cart", while "cab" lets them escape
+func newGhostGame(t *testing.T, answer, difficulty string) (WordBuilderState, *WordDictionary) {
+	t.Helper()
+	dict := NewWordDictionary([]string{"cart", "cabin"})
+	state := UpdateSets(WordBuilderState{Answer: answer}, dict)
+	state, err := StartGhostGame(state, difficulty)
+	if err != nil {
+		t.Fatalf("Failed to start ghost game: %v", err)
+	}
+	return state, dict
+}
+
+func TestStartGhostGame(t *testing.T) {
+	state, err := StartGhostGame(WordBuilderState{}, "")
+	if err != nil || state.Ghost == nil || state.Ghost.Difficulty != GhostGreedy {
+		t.Errorf("Expected a greedy ghost game by default, got %+v, %v", state.Ghost, err)
+	}
+	if _, err := StartGhostGame(WordBuilderState{}, "impossible"); err == nil {
+		t.Error("Expected an error for an unknown difficulty")
+	}
+}
+
+func TestGhostSolver(t *testing.T) {
+	dict := NewWordDictionary([]string{"cart", "cabin"})
+	solver := &ghostSolver{dict: dict, memo: make(map[string]bool), budget: ghostSearchBudget}
+
+	tests := []struct {
+		fragment string
+		wins     bool
+	}{
+		{"car", false}, // Only 't' is left, which completes "cart"
+		{"cab", true},  // 'i' leaves the opponent to complete "cabin"
+		{"ca", true},   // 'r' wins
+	}
+	for _, tt := range tests {
+		win, ok := solver.wins(tt.fragment)
+		if !ok {
+			t.Fatalf("Search for %q ran out of budget", tt.fragment)
+		}
+		if win != tt.wins {
+			t.Errorf("wins(%q) = %v, want %v", tt.fragment, win, tt.wins)
+		}
+	}
+}
+
+func TestGhostComputerMoves(t *testing.T) {
+	for _, difficulty := range []string{GhostGreedy, GhostPerfect} {
+		state, dict := newGhostGame(t, "c", difficulty)
+		state, _, err := GhostPlayerMove(state, dict, "a", "suffix")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", difficulty, err)
+		}
+		if state.Answer != "car" {
+			t.Errorf("%s: expected the computer to play 'r', got answer %q", difficulty, state.Answer)
+		}
+		if state.Ghost.Winner != "" {
+			t.Errorf("%s: round should not be over yet", difficulty)
+		}
+	}
+
+	// A random computer still only plays letters that keep a word in reach
+	for i := 0; i < 20; i++ {
+		state, dict := newGhostGame(t, "c", GhostRandom)
+		state, _, err := GhostPlayerMove(state, dict, "a", "suffix")
+		if err != nil {
+			t.Fatalf("random: unexpected error: %v", err)
+		}
+		if state.Answer != "car" && state.Answer != "cab" {
+			t.Errorf("random: unexpected answer %q", state.Answer)
+		}
+	}
+}
+
+func TestGhostPlayerCompletesWord(t *testing.T) {
+	state, dict := newGhostGame(t, "car", GhostPerfect)
+	state, _, err := GhostPlayerMove(state, dict, "t", "suffix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.Ghost.Winner != "computer" {
+		t.Errorf("Expected the computer to win, got %q", state.Ghost.Winner)
+	}
+
+	if _, _, err := GhostPlayerMove(state, dict, "s", "suffix"); err == nil {
+		t.Error("Expected an error when moving after the round is over")
+	}
+}
+
+func TestGhostMovesHideCompletions(t *testing.T) {
+	state, dict := newGhostGame(t, "c", GhostGreedy)
+	state, message, err := GhostPlayerMove(state, dict, "a", "suffix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(state.ValidCompletions) != 0 || state.GapSets != nil {
+		t.Errorf("Expected no completions or gap letters, got %v and %v", state.ValidCompletions, state.GapSets)
+	}
+	if strings.Contains(message, "cart") || strings.Contains(message, "cabin") {
+		t.Errorf("Expected the message not to name any word, got %q", message)
+	}
+}
+
+func TestFindWordContaining(t *testing.T) {
+	dict := NewWordDictionary([]string{"scarf", "cart", "carts", "bar", "scar"})
+	for fragment, want := range map[string]string{"car": "cart", "ar": "bar", "sca": "scar", "rts": "carts", "xyz": ""} {
+		if got := findWordContaining(dict, fragment); got != want {
+			t.Errorf("findWordContaining(%q) = %q, want %q", fragment, got, want)
+		}
+	}
+}
+
+func TestGhostChallenge(t *testing.T) {
+	state, dict := newGhostGame(t, "car", GhostGreedy)
+	state, _, err := GhostChallenge(state, dict)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.Ghost.Winner != "computer" || state.Ghost.Witness != "cart" {
+		t.Errorf("Expected the computer to win with 'cart', got %+v", state.Ghost)
+	}
+
+	// A fragment no word contains, as left by a bluff
+	state, dict = newGhostGame(t, "", GhostGreedy)
+	state = forceLetter(state, dict, "x", "suffix")
+	state, _, err = GhostChallenge(state, dict)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.Ghost.Winner != "player" || state.Ghost.Witness != "" {
+		t.Errorf("Expected the player to win, got %+v", state.Ghost)
+	}
+
+	if _, _, err := GhostChallenge(state, dict); err == nil {
+		t.Error("Expected an error when challenging after the round is over")
+	}
+}
+
+func TestGhostViewHidesBluff(t *testing.T) {
+	view := GetGhostView(&GhostState{Difficulty: GhostPerfect, Bluffed: true})
+	if _, exists := view["bluffed"]; exists {
+		t.Error("The view must not reveal whether the computer bluffed")
+	}
+	if GetGhostView(nil) != nil {
+		t.Error("Expected no view outside ghost games")
+	}
+
+	// A fragment left by a bluff must look like any other
+	honest, dict := newGhostGame(t, "", GhostGreedy)
+	honest = ConstrainToGhost(forceLetter(honest, dict, "c", "suffix"))
+	bluffed := ConstrainToGhost(forceLetter(honest, dict, "x", "suffix"))
+	bluffed.Ghost = &GhostState{Difficulty: GhostGreedy, Bluffed: true}
+	honestView, bluffedView := GetCurrentState(honest), GetCurrentState(bluffed)
+	for _, key := range []string{"prefix_set", "suffix_set", "gap_sets", "valid_completions", "is_valid_word", "ghost"} {
+		if !reflect.DeepEqual(honestView[key], bluffedView[key]) {
+			t.Errorf("The %s of a bluffed state differs: %v, honestly %v", key, bluffedView[key], honestView[key])
+		}
+	}
+}
+
+func TestGhostPlayerBluffs(t *testing.T) {
+	state, dict := newGhostGame(t, "c", GhostGreedy)
+	if _, _, err := GhostPlayerMove(state, dict, "?", "suffix"); err == nil {
+		t.Error("Expected an error for a letter outside the alphabet")
+	}
+
+	// Any letter of the alphabet can be played, but the computer calls a bluff
+	state, message, err := GhostPlayerMove(state, dict, "t", "prefix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state.Answer != "tc" || state.Ghost.Winner != "computer" || !strings.Contains(message, "challenges") {
+		t.Errorf("Expected the computer to call the bluff, got %q: %+v", state.Answer, state.Ghost)
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// WordGraph holds the words of a dictionary for lookups and searches. A Trie
+// is quick to build, while a DAWG shares common suffixes and takes a
+// fraction of the memory. Both list words in sorted order.
+type WordGraph interface {
+	TrieI
+	Contains(word string) bool
+	MatchPattern(pattern string) ([]string, error)
+	MatchPatternAfter(pattern, cursor string, limit int) ([]string, error)
+	WithinDistance(word string, maxDistance int) []SimilarWord
+	root() graphNode
+}
+
+// graphNode is a node of a word graph, as the searches walk it. Children are
+// visited in the order of their letters.
+type graphNode interface {
+	isWord() bool
+	child(ch rune) (graphNode, bool)
+	eachChild(fn func(ch rune, child graphNode))
+}
+
+func (n *TrieNode) isWord() bool {
+	return n.IsWord
+}
+
+func (n *TrieNode) child(ch rune) (graphNode, bool) {
+	child, exists := n.Children[ch]
+	return child, exists
+}
+
+func (n *TrieNode) eachChild(fn func(ch rune, child graphNode)) {
+	for _, ch := range n.letters {
+		fn(ch, n.Children[ch])
+	}
+}
+
+func (t *Trie) root() graphNode {
+	return t.Root
+}
+
+// graphNodeAt follows prefix from root and returns the node it ends at
+func graphNodeAt(root graphNode, prefix string) (graphNode, bool) {
+	node := root
+	for _, ch := range prefix {
+		next, exists := node.child(ch)
+		if !exists {
+			return nil, false
+		}
+		node = next
+	}
+	return node, true
+}
+
+// keysWithPrefix lists the words of a graph starting with prefix in order,
+// only those after cursor unless it is empty, stopping once limit words are
+// found unless limit is 0
+func keysWithPrefix(root graphNode, prefix, cursor string, limit int) []string {
+	var results []string
+	node, exists := graphNodeAt(root, prefix)
+	if !exists {
+		return results
+	}
+
+	// While the word walked is a prefix of the cursor, the branches before
+	// the cursor's next letter only hold words up to the cursor
+	bounded := false
+	if cursor != "" {
+		switch {
+		case strings.HasPrefix(cursor, prefix):
+			bounded = true
+		case cursor > prefix:
+			return results
+		}
+	}
+	bound := []rune(cursor)
+
+	var collect func(node graphNode, word []rune, bounded bool)
+	collect = func(node graphNode, word []rune, bounded bool) {
+		if limit > 0 && len(results) >= limit {
+			return
+		}
+		if node.isWord() && !bounded {
+			results = append(results, string(word))
+		}
+		node.eachChild(func(ch rune, child graphNode) {
+			switch {
+			case !bounded || len(word) == len(bound) || ch > bound[len(word)]:
+				collect(child, append(word, ch), false)
+			case ch == bound[len(word)]:
+				collect(child, append(word, ch), true)
+			}
+		})
+	}
+	collect(node, []rune(prefix), bounded)
+	return results
+}
+
+// graphWords spells out the words of a graph one at a time, in order,
+// without holding them all
+func graphWords(root graphNode) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		var word []rune
+		stopped := false
+		var walk func(node graphNode)
+		walk = func(node graphNode) {
+			if node.isWord() && !yield(string(word)) {
+				stopped = true
+				return
+			}
+			node.eachChild(func(ch rune, child graphNode) {
+				if stopped {
+					return
+				}
+				word = append(word, ch)
+				walk(child)
+				word = word[:len(word)-1]
+			})
+		}
+		walk(root)
+	}
+}
+
+// dictWords lists the words of a dictionary by walking its forward graph,
+// in sorted order, so a compact dictionary doesn't spell them all out at
+// once. Dictionaries without a graph give their word list.
+func dictWords(dict WordDictionaryI) iter.Seq[string] {
+	if graph, ok := dict.GetForwardTrie().(WordGraph); ok {
+		return graphWords(graph.root())
+	}
+	return slices.Values(dict.GetWordList())
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	utils "wordbuilder/utils"
+)
+
+// Hint levels, each giving away more than the last. Asking again without
+// moving escalates to the next level.
+const (
+	HintLetterCount = iota + 1 // How many letters the word to build has
+	HintNextLetter             // The next letter to add
+	HintPosition               // The next letter and where to add it
+	HintCompletion             // The whole word
+)
+
+// Hint records a hint taken during a session
+type Hint struct {
+	Level  int    `json:"level"`
+	Step   int    `json:"step"`             // Step the hint was taken at
+	Answer string `json:"answer"`           // Answer the hint was taken for
+	Target string `json:"target,omitempty"` // Word the hint leads to, only shown once fully revealed
+	Text   string `json:"text"`
+}
+
+// TakeHint gives the next hint toward a word that can be built from the
+// answer and records it on the state. Taking a hint is a move of its own, so
+// callers charge for it.
+func TakeHint(state WordBuilderState, dict WordDictionaryI) (WordBuilderState, string, error) {
+	level, target := nextHint(state)
+	if level > HintCompletion {
+		return state, "No more hints for this answer. Try adding a letter.", fmt.Errorf("no more hints")
+	}
+	if target == "" {
+		target = hintTarget(state, dict)
+	}
+	if target == "" {
+		return state, "No word can be built from here. Try removing some letters.", fmt.Errorf("no hint available")
+	}
+	letter, position, ok := hintLetter(state, target)
+	if !ok {
+		return state, "No word can be built from here. Try removing some letters.", fmt.Errorf("no hint available")
+	}
+
+	var text string
+	switch level {
+	case HintLetterCount:
+		text = fmt.Sprintf("There is a %d-letter word to build from here.", utf8.RuneCountInString(target))
+	case HintNextLetter:
+		text = fmt.Sprintf("Try the letter '%s'.", letter)
+	case HintPosition:
+		text = fmt.Sprintf("Add '%s' as %s.", letter, position)
+	case HintCompletion:
+		text = fmt.Sprintf("Try building '%s'.", target)
+	}
+
+	hint := Hint{Level: level, Step: state.Step, Answer: state.Answer, Target: target, Text: text}
+	hints := make([]Hint, len(state.Hints), len(state.Hints)+1)
+	copy(hints, state.Hints)
+	state.Hints = append(hints, hint)
+	return state, fmt.Sprintf("Hint %d: %s", level, text), nil
+}
+
+// nextHint returns the level of the next hint and the word the hints taken
+// since the last move lead to, if any
+func nextHint(state WordBuilderState) (int, string) {
+	if len(state.Hints) == 0 {
+		return HintLetterCount, ""
+	}
+	last := state.Hints[len(state.Hints)-1]
+	if last.Step != state.Step || last.Answer != state.Answer {
+		return HintLetterCount, ""
+	}
+	return last.Level + 1, last.Target
+}
+
+// nextHintLevel returns the level of the next hint, 0 once the hints for the
+// answer are used up
+func nextHintLevel(state WordBuilderState) int {
+	if level, _ := nextHint(state); level <= HintCompletion {
+		return level
+	}
+	return 0
+}
+
+// hintTarget picks the word hints lead to: the target of a puzzle, the
+// easiest daily word left to find, or else the easiest word containing the
+// answer, which on a rack must be buildable from its tiles
+func hintTarget(state WordBuilderState, dict WordDictionaryI) string {
+	if state.Puzzle != nil {
+		if state.Puzzle.Solved {
+			return ""
+		}
+		return state.Puzzle.Target
+	}
+
+	var candidates []string
+	if state.Daily != nil {
+		for _, target := range state.Daily.Targets {
+			if !slices.Contains(state.Daily.Found, target) {
+				candidates = append(candidates, target)
+			}
+		}
+	}
+	if target := easiestContaining(slices.Values(candidates), state.Answer, dict, nil); target != "" {
+		return target
+	}
+	return easiestContaining(wordsContaining(dict, state.Answer), state.Answer, dict, state.Rack)
+}
+
+// wordsContaining lists the words containing fragment. It grows fragment to
+// the left through the reverse substring index until it starts a word, then
+// walks the forward graph for the ways to finish it, so only words holding
+// fragment are visited. A word comes once, from the first place it holds
+// fragment.
+func wordsContaining(dict WordDictionaryI, fragment string) iter.Seq[string] {
+	graph, ok := dict.GetForwardTrie().(WordGraph)
+	index := dict.GetReverseSubstringIndex()
+	if !ok || index == nil || fragment == "" {
+		return dictWords(dict)
+	}
+
+	return func(yield func(string) bool) {
+		starts := []string{fragment}
+		for len(starts) > 0 {
+			start := starts[len(starts)-1]
+			starts = starts[:len(starts)-1]
+			if node, ok := graphNodeAt(graph.root(), start); ok {
+				first := len(start) - len(fragment)
+				for rest := range graphWords(node) {
+					word := start + rest
+					if strings.Index(word, fragment) == first && !yield(word) {
+						return
+					}
+				}
+			}
+			for _, letter := range index.GetNextLetters(utils.ReverseString(start)) {
+				starts = append(starts, letter+start)
+			}
+		}
+	}
+}
+
+// easiestContaining returns the word longer than fragment containing it that
+// is easiest to guess: the commonest when the dictionary knows, then the
+// shortest, then the first in alphabetical order. With a rack, only the
+// words the rack can build count.
+func easiestContaining(words iter.Seq[string], fragment string, dict WordDictionaryI, rack *RackState) string {
+	frequency := frequencyOf(dict)
+	best, bestLength, bestFrequency := "", 0, 0.0
+	for word := range words {
+		length := utf8.RuneCountInString(word)
+		if length <= utf8.RuneCountInString(fragment) || !strings.Contains(word, fragment) {
+			continue
+		}
+		if rack != nil {
+			if _, ok := rack.Remaining(word); !ok {
+				continue
+			}
+		}
+		f := frequency(word)
+		switch {
+		case best == "", f > bestFrequency:
+		case f < bestFrequency, length > bestLength, length == bestLength && word > best:
+			continue
+		}
+		best, bestLength, bestFrequency = word, length, f
+	}
+	return best
+}
+
+// hintLetter returns a letter the letter sets offer that leads from the
+// answer toward target, and where to add it
+func hintLetter(state WordBuilderState, target string) (string, string, bool) {
+	runes := []rune(target)
+	if state.Answer == "" {
+		letter := string(runes[0])
+		return letter, "prefix", state.PrefixSet[letter]
+	}
+
+	// Rune offset of the answer inside the target
+	index := strings.Index(target, state.Answer)
+	if state.Puzzle != nil {
+		index = state.Puzzle.Start
+	} else if index >= 0 {
+		index = utf8.RuneCountInString(target[:index])
+	}
+	if index < 0 {
+		return "", "", false
+	}
+
+	end := index + utf8.RuneCountInString(state.Answer)
+	if end < len(runes) && state.SuffixSet[string(runes[end])] {
+		return string(runes[end]), "suffix", true
+	}
+	if index > 0 && state.PrefixSet[string(runes[index-1])] {
+		return string(runes[index-1]), "prefix", true
+	}
+	return "", "", false
+}
+
+// GetHintsView lists the hints taken for the API, without the words they
+// lead to unless they were given away
+func GetHintsView(hints []Hint) []Hint {
+	view := make([]Hint, len(hints))
+	for i, hint := range hints {
+		if hint.Level < HintCompletion {
+			hint.Target = ""
+		}
+		view[i] = hint
+	}
+	return view
+}
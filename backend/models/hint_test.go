@@ -0,0 +1,96 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTakeHintEscalates(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{Answer: "an"}, dict)
+
+	// "can" is the shortest word containing "an"
+	want := []string{
+		"There is a 3-letter word to build from here.",
+		"Try the letter 'c'.",
+		"Add 'c' as prefix.",
+		"Try building 'can'.",
+	}
+	for i, text := range want {
+		var err error
+		state, _, err = TakeHint(state, dict)
+		if err != nil {
+			t.Fatalf("Unexpected error taking hint %d: %v", i+1, err)
+		}
+		hint := state.Hints[len(state.Hints)-1]
+		if hint.Level != i+1 || hint.Text != text {
+			t.Errorf("Hint %d = %+v, want %q", i+1, hint, text)
+		}
+	}
+	if _, _, err := TakeHint(state, dict); err == nil {
+		t.Error("Expected an error once the hints are used up")
+	}
+
+	// Hints only give the word away at the last level
+	view := GetHintsView(state.Hints)
+	if view[0].Target != "" || view[3].Target != "can" || state.Hints[0].Target != "can" {
+		t.Errorf("Unexpected hints view %+v", view)
+	}
+
+	// Moving starts over from the first level
+	state, _, _ = AddLetter(state, dict, "d", "suffix")
+	state, _, _ = TakeHint(state, dict)
+	if hint := state.Hints[len(state.Hints)-1]; hint.Level != HintLetterCount || hint.Target != "band" || len(state.Hints) != 5 {
+		t.Errorf("Expected a first hint toward 'band', got %+v", hint)
+	}
+}
+
+func TestTakeHintLeadsToPuzzleTarget(t *testing.T) {
+	dict := NewWordDictionary([]string{"cart", "car", "at", "art"})
+	state := StartPuzzle(UpdateSets(WordBuilderState{}, dict), dict, PuzzleState{Target: "cart", Seed: "a", Start: 1})
+
+	state, _, _ = TakeHint(state, dict)
+	state, _, _ = TakeHint(state, dict)
+	state, _, err := TakeHint(state, dict)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// "at" is shorter, but only "art" leads to the target
+	if hint := state.Hints[2]; hint.Text != "Add 'r' as suffix." {
+		t.Errorf("Expected a hint toward the target, got %+v", hint)
+	}
+}
+
+func TestTakeHintWithoutWords(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{Answer: "bandana"}, dict)
+	if _, _, err := TakeHint(state, dict); err == nil {
+		t.Error("Expected an error when no longer word contains the answer")
+	}
+}
+
+func TestTakeHintPrefersCommonWords(t *testing.T) {
+	// "banana" is more common than the shorter "can" and "band"
+	dict := NewWeightedWordDictionary(sampleWords, map[string]float64{"banana": 0.9, "can": 0.3}, DictionaryOptions{})
+	state := UpdateSets(WordBuilderState{Answer: "an"}, dict)
+	state, _, err := TakeHint(state, dict)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hint := state.Hints[0]; hint.Target != "banana" {
+		t.Errorf("Expected a hint toward 'banana', got %+v", hint)
+	}
+}
+
+func TestWordsContaining(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		dict := NewWordDictionaryWithOptions(sampleWords, DictionaryOptions{Compact: compact})
+		got := slices.Sorted(wordsContaining(dict, "an"))
+		if !equalStringSlices(got, []string{"banana", "band", "bandana", "can"}) {
+			t.Errorf("compact=%v: wordsContaining(\"an\") = %v", compact, got)
+		}
+		if got := slices.Collect(wordsContaining(dict, "nd")); !equalStringSlices(got, []string{"band", "bandana"}) {
+			t.Errorf("compact=%v: wordsContaining(\"nd\") = %v", compact, got)
+		}
+	}
+}
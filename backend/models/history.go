@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Move records a single change to the answer so it can be undone and redone
+type Move struct {
+	Action   string `json:"action"`             // "add" or "remove"
+	Letter   string `json:"letter"`             // Letter added or removed
+	Position string `json:"position,omitempty"` // "prefix", "suffix" or "insert" for additions
+	Index    int    `json:"index"`              // Letter index in the answer it was added to or removed from
+	Answer   string `json:"answer"`             // Answer after the move
+}
+
+// appendMove returns a copy of moves with move appended, so states sharing
+// the original slice are never modified
+func appendMove(moves []Move, move Move) []Move {
+	result := make([]Move, len(moves), len(moves)+1)
+	copy(result, moves)
+	return append(result, move)
+}
+
+// recordMove adds a move to the history and clears the redo stack, as a new
+// move starts a new branch of history
+func recordMove(state WordBuilderState, move Move) WordBuilderState {
+	state.History = appendMove(state.History, move)
+	state.Undone = nil
+	return state
+}
+
+// UndoMove reverts the most recent move in the history
+func UndoMove(state WordBuilderState, dict WordDictionaryI) (WordBuilderState, string, error) {
+	if len(state.History) == 0 {
+		return state, "Nothing to undo.", fmt.Errorf("nothing to undo")
+	}
+	last := state.History[len(state.History)-1]
+
+	var newState WordBuilderState
+	var err error
+	switch last.Action {
+	case "add":
+		index := 0
+		switch last.Position {
+		case "suffix":
+			index = utf8.RuneCountInString(state.Answer) - 1
+		case "insert":
+			index = last.Index
+		}
+		newState, _, err = RemoveLetter(state, dict, index)
+	case "remove":
+		runes := []rune(state.Answer)
+		if last.Index > len(runes) {
+			return state, "Failed to undo the last move.", fmt.Errorf("history does not match the answer")
+		}
+		previous := string(runes[:last.Index]) + last.Letter + string(runes[last.Index:])
+		switch last.Index {
+		case 0:
+			newState, _, err = AddLetter(state, dict, last.Letter, "prefix")
+		case len(runes):
+			newState, _, err = AddLetter(state, dict, last.Letter, "suffix")
+		default:
+			newState, _, err = InsertLetter(state, dict, last.Letter, last.Index)
+		}
+		// The answer before the removal may not have been a valid fragment,
+		// in which case the letter sets don't offer the letter back
+		if err != nil {
+			newState, err = restoreAnswer(state, dict, previous), nil
+		}
+	default:
+		return state, fmt.Sprintf("Unknown move '%s'.", last.Action), fmt.Errorf("unknown move")
+	}
+	if err != nil {
+		return state, "Failed to undo the last move.", err
+	}
+
+	newState.History = state.History[: len(state.History)-1 : len(state.History)-1]
+	newState.Undone = appendMove(state.Undone, last)
+
+	message := fmt.Sprintf("Step %d: Undid %s '%s' -> Answer: %s", newState.Step, moveName(last), last.Letter, newState.Answer)
+	return newState, message, nil
+}
+
+// RedoMove reapplies the most recently undone move
+func RedoMove(state WordBuilderState, dict WordDictionaryI) (WordBuilderState, string, error) {
+	if len(state.Undone) == 0 {
+		return state, "Nothing to redo.", fmt.Errorf("nothing to redo")
+	}
+	next := state.Undone[len(state.Undone)-1]
+
+	var newState WordBuilderState
+	var err error
+	switch next.Action {
+	case "add":
+		if next.Position == "insert" {
+			newState, _, err = InsertLetter(state, dict, next.Letter, next.Index)
+		} else {
+			newState, _, err = AddLetter(state, dict, next.Letter, next.Position)
+		}
+	case "remove":
+		newState, _, err = RemoveLetter(state, dict, next.Index)
+	default:
+		return state, fmt.Sprintf("Unknown move '%s'.", next.Action), fmt.Errorf("unknown move")
+	}
+	if err != nil {
+		return state, "Failed to redo the move.", err
+	}
+
+	// Replaying the move recorded it again and cleared the redo stack
+	newState.Undone = state.Undone[: len(state.Undone)-1 : len(state.Undone)-1]
+
+	message := fmt.Sprintf("Step %d: Redid %s '%s' -> Answer: %s", newState.Step, moveName(next), next.Letter, newState.Answer)
+	return newState, message, nil
+}
+
+// restoreAnswer sets the answer directly, for undoing moves the letter sets
+// can't express
+func restoreAnswer(state WordBuilderState, dict WordDictionaryI, answer string) WordBuilderState {
+	newState := state
+	newState.Answer = answer
+	newState.IsValidWord = CheckValidWord(newState, dict)
+	newState = UpdateSets(newState, dict)
+	newState.Step++
+	return newState
+}
+
+// moveName describes a move for messages
+func moveName(move Move) string {
+	if move.Action == "add" {
+		return "adding"
+	}
+	return "removing"
+}
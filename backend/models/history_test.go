@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestUndoRedoAddLetter(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{}, dict)
+
+	state, _, err := AddLetter(state, dict, "c", "prefix")
+	if err != nil {
+		t.Fatalf("AddLetter() failed: %v", err)
+	}
+	state, _, err = AddLetter(state, dict, "a", "suffix")
+	if err != nil {
+		t.Fatalf("AddLetter() failed: %v", err)
+	}
+	if len(state.History) != 2 || state.History[1].Answer != "ca" {
+		t.Fatalf("Expected 2 moves ending in 'ca', got %+v", state.History)
+	}
+
+	undone, _, err := UndoMove(state, dict)
+	if err != nil || undone.Answer != "c" {
+		t.Fatalf("UndoMove() = %q, %v; want 'c'", undone.Answer, err)
+	}
+	if len(undone.History) != 1 || len(undone.Undone) != 1 {
+		t.Errorf("Expected 1 move in history and 1 to redo, got %+v / %+v", undone.History, undone.Undone)
+	}
+
+	redone, _, err := RedoMove(undone, dict)
+	if err != nil || redone.Answer != "ca" {
+		t.Fatalf("RedoMove() = %q, %v; want 'ca'", redone.Answer, err)
+	}
+	if len(redone.History) != 2 || len(redone.Undone) != 0 {
+		t.Errorf("Expected 2 moves in history and none to redo, got %+v / %+v", redone.History, redone.Undone)
+	}
+
+	// The original state must not be affected by undo/redo
+	if len(state.History) != 2 || state.Answer != "ca" {
+		t.Errorf("Original state was modified: %+v", state)
+	}
+}
+
+func TestUndoRemoveLetter(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{}, dict)
+	for _, letter := range []string{"b", "a", "n", "d"} {
+		var err error
+		state, _, err = AddLetter(state, dict, letter, "suffix")
+		if err != nil {
+			t.Fatalf("AddLetter(%q) failed: %v", letter, err)
+		}
+	}
+
+	// Removing from the middle can only be undone by restoring the answer
+	state, _, err := RemoveLetter(state, dict, 1)
+	if err != nil || state.Answer != "bnd" {
+		t.Fatalf("RemoveLetter() = %q, %v; want 'bnd'", state.Answer, err)
+	}
+	state, _, err = UndoMove(state, dict)
+	if err != nil || state.Answer != "band" || !state.IsValidWord {
+		t.Fatalf("UndoMove() = %q (valid %v), %v; want valid 'band'", state.Answer, state.IsValidWord, err)
+	}
+
+	state, _, err = RedoMove(state, dict)
+	if err != nil || state.Answer != "bnd" {
+		t.Fatalf("RedoMove() = %q, %v; want 'bnd'", state.Answer, err)
+	}
+}
+
+func TestNewMoveClearsRedo(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{}, dict)
+	state, _, _ = AddLetter(state, dict, "c", "prefix")
+	state, _, _ = UndoMove(state, dict)
+	if len(state.Undone) != 1 {
+		t.Fatalf("Expected a move to redo, got %+v", state.Undone)
+	}
+
+	state, _, _ = AddLetter(state, dict, "b", "prefix")
+	if len(state.Undone) != 0 {
+		t.Errorf("Expected redo stack cleared by a new move, got %+v", state.Undone)
+	}
+	if _, _, err := RedoMove(state, dict); err == nil {
+		t.Error("Expected error when there is nothing to redo")
+	}
+}
+
+func TestUndoEmptyHistory(t *testing.T) {
+	dict := newTestDictionary()
+	if _, _, err := UndoMove(WordBuilderState{}, dict); err == nil {
+		t.Error("Expected error when there is nothing to undo")
+	}
+}
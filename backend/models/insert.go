@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InsertLetter adds a letter inside the answer, before the letter at index.
+// Index 0 and the length of the answer add at the ends, like AddLetter.
+func InsertLetter(state WordBuilderState, dict WordDictionaryI, letter string, index int) (WordBuilderState, string, error) {
+	letter = strings.ToLower(letter)
+	runes := []rune(state.Answer)
+	switch {
+	case index < 0 || index > len(runes):
+		return state, fmt.Sprintf("Invalid index %d for answer '%s'.", index, state.Answer), fmt.Errorf("invalid index")
+	case index == 0:
+		return AddLetter(state, dict, letter, "prefix")
+	case index == len(runes):
+		return AddLetter(state, dict, letter, "suffix")
+	}
+
+	if !IsLetter(dict, letter) {
+		return state, fmt.Sprintf("'%s' is not a letter of this word list.", letter), fmt.Errorf("invalid letter")
+	}
+	if index > len(state.GapSets) || !state.GapSets[index-1][letter] {
+		return state, fmt.Sprintf("Invalid letter '%s' at index %d.", letter, index), fmt.Errorf("invalid insert letter")
+	}
+
+	newState := state
+	newState.Answer = string(runes[:index]) + letter + string(runes[index:])
+	newState.IsValidWord = CheckValidWord(newState, dict)
+	newState = UpdateSets(newState, dict)
+	newState.Step++
+	newState = recordMove(newState, Move{Action: "add", Letter: letter, Position: "insert", Index: index, Answer: newState.Answer})
+
+	message := fmt.Sprintf("Step %d: Inserted '%s' at index %d -> Answer: %s", newState.Step, letter, index, newState.Answer)
+	if newState.IsValidWord {
+		message += fmt.Sprintf("\n*** '%s' is a valid word! ***", newState.Answer)
+	}
+	return newState, message, nil
+}
+
+// gapSets lists, for each gap between two letters of the answer, the letters
+// that can go there while keeping the answer inside some word. Entry i is
+// the gap before the letter at index i+1.
+func gapSets(answer string, dict WordDictionaryI) []map[string]bool {
+	runes := []rune(answer)
+	if len(runes) < 2 {
+		return nil
+	}
+	index := dict.GetSubstringIndex()
+
+	gaps := make([]map[string]bool, len(runes)-1)
+	for i := range gaps {
+		gaps[i] = make(map[string]bool)
+		if index == nil {
+			continue
+		}
+		left, right := string(runes[:i+1]), string(runes[i+1:])
+		for _, letter := range index.GetNextLetters(left) {
+			if dictHasFragment(dict, left+letter+right) {
+				gaps[i][letter] = true
+			}
+		}
+	}
+	return gaps
+}
+
+// GetGapSetsView lists the letters that can be inserted at each interior index
+func GetGapSetsView(gaps []map[string]bool) []map[string]interface{} {
+	view := make([]map[string]interface{}, 0, len(gaps))
+	for i, gap := range gaps {
+		letters := make([]string, 0, len(gap))
+		for letter := range gap {
+			letters = append(letters, letter)
+		}
+		sort.Strings(letters)
+		view = append(view, map[string]interface{}{
+			"index":   i + 1,
+			"letters": letters,
+		})
+	}
+	return view
+}
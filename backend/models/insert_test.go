@@ -0,0 +1,63 @@
+package models
+
+import "testing"
+
+func TestGapSets(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{Answer: "bnd"}, dict)
+
+	// Only "band" fits, with 'a' between 'b' and 'n'
+	if len(state.GapSets) != 2 || len(state.GapSets[0]) != 1 || !state.GapSets[0]["a"] || len(state.GapSets[1]) != 0 {
+		t.Fatalf("Unexpected gap sets %v", state.GapSets)
+	}
+	view := GetGapSetsView(state.GapSets)
+	if view[0]["index"] != 1 || !equalStringSlices(view[0]["letters"].([]string), []string{"a"}) {
+		t.Errorf("Unexpected gap sets view %v", view)
+	}
+
+	if state := UpdateSets(WordBuilderState{Answer: "a"}, dict); len(state.GapSets) != 0 {
+		t.Errorf("Expected no gaps in a single letter, got %v", state.GapSets)
+	}
+}
+
+func TestInsertLetter(t *testing.T) {
+	dict := newTestDictionary()
+	state := UpdateSets(WordBuilderState{Answer: "bnd"}, dict)
+
+	if _, _, err := InsertLetter(state, dict, "e", 1); err == nil {
+		t.Error("Expected an error for a letter that fits no word")
+	}
+	if _, _, err := InsertLetter(state, dict, "a", 4); err == nil {
+		t.Error("Expected an error for an index past the end")
+	}
+
+	state, _, err := InsertLetter(state, dict, "A", 1)
+	if err != nil || state.Answer != "band" || !state.IsValidWord {
+		t.Fatalf("Expected 'band', got %q: %v", state.Answer, err)
+	}
+	if last := state.History[len(state.History)-1]; last.Position != "insert" || last.Index != 1 {
+		t.Errorf("Unexpected move %+v", last)
+	}
+
+	state, _, _ = UndoMove(state, dict)
+	if state.Answer != "bnd" {
+		t.Fatalf("Expected undo to give 'bnd', got %q", state.Answer)
+	}
+	state, _, _ = RedoMove(state, dict)
+	if state.Answer != "band" {
+		t.Fatalf("Expected redo to give 'band', got %q", state.Answer)
+	}
+
+	// Removing a letter from the middle is undone by inserting it back
+	state, _, _ = RemoveLetter(state, dict, 2)
+	state, _, err = UndoMove(state, dict)
+	if err != nil || state.Answer != "band" {
+		t.Errorf("Expected undo to give 'band', got %q: %v", state.Answer, err)
+	}
+
+	// The ends go through the prefix and suffix sets
+	state, _, err = InsertLetter(state, dict, "a", 4)
+	if err != nil || state.Answer != "banda" {
+		t.Errorf("Expected 'banda', got %q: %v", state.Answer, err)
+	}
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Unicode normalization forms a word list can be stored in
+const (
+	NormalizeNFC  = "nfc"  // Composed, so accented letters are a single letter (default)
+	NormalizeNFD  = "nfd"  // Decomposed, so accents are letters of their own
+	NormalizeNone = "none" // Kept as written
+)
+
+// How the frequency column of a word list is read
+const (
+	FrequencyCount = "count" // Occurrences of the word, the more the commoner (default)
+	FrequencyRank  = "rank"  // Position in a list of the commonest words, from 1
+)
+
+// NormalizeOptions configures how the entries of a word list are cleaned up
+// when it is loaded. The options are stored with the word list so reloading
+// it always yields the same words.
+type NormalizeOptions struct {
+	Form             string `json:"form"`              // One of the Normalize constants, empty for NFC
+	FoldDiacritics   bool   `json:"fold_diacritics"`   // Strip accents, so "café" becomes "cafe"
+	StripPunctuation bool   `json:"strip_punctuation"` // Drop punctuation and symbols, so "don't" becomes "dont"
+	StripDigits      bool   `json:"strip_digits"`
+	RejectMultiWord  bool   `json:"reject_multi_word"` // Drop entries with spaces instead of keeping them whole
+	MinLength        int    `json:"min_length"`        // Shortest word kept in letters, 0 for no minimum
+	MaxLength        int    `json:"max_length"`        // Longest word kept in letters, 0 for no maximum
+	Frequency        string `json:"frequency"`         // One of the Frequency constants, empty for counts
+}
+
+// Validate checks that the options are consistent
+func (o NormalizeOptions) Validate() error {
+	switch o.Form {
+	case "", NormalizeNFC, NormalizeNFD, NormalizeNone:
+	default:
+		return fmt.Errorf("unknown normalization form '%s'", o.Form)
+	}
+	switch o.Frequency {
+	case "", FrequencyCount, FrequencyRank:
+	default:
+		return fmt.Errorf("unknown frequency column '%s'", o.Frequency)
+	}
+	if o.MinLength < 0 || o.MaxLength < 0 {
+		return fmt.Errorf("lengths can't be negative")
+	}
+	if o.MaxLength > 0 && o.MinLength > o.MaxLength {
+		return fmt.Errorf("min length %d is greater than max length %d", o.MinLength, o.MaxLength)
+	}
+	return nil
+}
+
+// NormalizeWord cleans up a word list entry, lowercasing it and applying the
+// options. It returns false when the entry should be dropped.
+func NormalizeWord(entry string, opts NormalizeOptions) (string, bool) {
+	word := strings.ToLower(strings.TrimSpace(entry))
+
+	if opts.FoldDiacritics {
+		// Decompose, drop the combining marks and compose what's left
+		folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), word)
+		if err == nil {
+			word = folded
+		}
+	}
+	if opts.StripPunctuation || opts.StripDigits {
+		word = strings.Map(func(r rune) rune {
+			if opts.StripPunctuation && (unicode.IsPunct(r) || unicode.IsSymbol(r)) || opts.StripDigits && unicode.IsDigit(r) {
+				return -1
+			}
+			return r
+		}, word)
+	}
+
+	word = strings.Join(strings.Fields(word), " ")
+	if word == "" || opts.RejectMultiWord && strings.Contains(word, " ") {
+		return "", false
+	}
+
+	switch opts.Form {
+	case "", NormalizeNFC:
+		word = norm.NFC.String(word)
+	case NormalizeNFD:
+		word = norm.NFD.String(word)
+	}
+
+	length := utf8.RuneCountInString(word)
+	if length < opts.MinLength || opts.MaxLength > 0 && length > opts.MaxLength {
+		return "", false
+	}
+	return word, true
+}
+
+// NormalizeWords cleans up the entries of a word list, dropping rejected
+// entries and the duplicates normalization leaves behind
+func NormalizeWords(entries []string, opts NormalizeOptions) []string {
+	words, _ := NormalizeWeightedWords(entries, opts)
+	return words
+}
+
+// NormalizeWeightedWords cleans up the entries of a word list like
+// NormalizeWords, also reading how common each word is from an optional
+// frequency column. Frequencies are scaled from 0 for the rarest to 1 for the
+// commonest, on a log scale as word counts span orders of magnitude. Words
+// without a frequency in a list that has some get 0, and the map is nil when
+// no entry has one.
+func NormalizeWeightedWords(entries []string, opts NormalizeOptions) ([]string, map[string]float64) {
+	seen := make(map[string]bool, len(entries))
+	words := make([]string, 0, len(entries))
+	values := make(map[string]float64)
+	highest := 0.0
+	for _, entry := range entries {
+		entry, value, weighted := SplitFrequency(entry)
+		word, ok := NormalizeWord(entry, opts)
+		if !ok {
+			continue
+		}
+		if weighted {
+			if opts.Frequency == FrequencyRank {
+				value = max(value, 1)
+			}
+			// Entries folded into the same word keep the commonest
+			if previous, ok := values[word]; !ok || opts.Frequency == FrequencyRank && value < previous || opts.Frequency != FrequencyRank && value > previous {
+				values[word] = value
+			}
+			highest = max(highest, value)
+		}
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if len(values) == 0 {
+		return words, nil
+	}
+
+	frequencies := make(map[string]float64, len(words))
+	for _, word := range words {
+		value, ok := values[word]
+		switch {
+		case !ok:
+			frequencies[word] = 0
+		case opts.Frequency == FrequencyRank:
+			frequencies[word] = 1 - math.Log(value)/math.Log(highest+1)
+		case highest > 0:
+			frequencies[word] = math.Log1p(value) / math.Log1p(highest)
+		default:
+			frequencies[word] = 0
+		}
+	}
+	return words, frequencies
+}
+
+// SplitFrequency splits the frequency column off a word list entry. The
+// column is the last field after a tab, as ConvertWordList writes it, and
+// must be a number that isn't negative. A space doesn't start the column,
+// so phrases ending in a number such as "catch 22" stay whole.
+func SplitFrequency(entry string) (string, float64, bool) {
+	entry = strings.TrimSpace(entry)
+	i := strings.LastIndexByte(entry, '\t')
+	if i < 0 {
+		return entry, 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(entry[i+1:]), 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return entry, 0, false
+	}
+	return strings.TrimSpace(entry[:i]), value, true
+}
@@ -0,0 +1,81 @@
+package models
+
+import "testing"
+
+func TestNormalizeWord(t *testing.T) {
+	decomposed := "cafe\u0301" // "café" with a combining accent
+	tests := []struct {
+		entry string
+		opts  NormalizeOptions
+		want  string
+		ok    bool
+	}{
+		{"  Apple ", NormalizeOptions{}, "apple", true},
+		{decomposed, NormalizeOptions{}, "café", true},
+		{"café", NormalizeOptions{Form: NormalizeNFD}, decomposed, true},
+		{decomposed, NormalizeOptions{Form: NormalizeNone}, decomposed, true},
+		{"Crème Brûlée", NormalizeOptions{FoldDiacritics: true}, "creme brulee", true},
+		{"Crème  Brûlée", NormalizeOptions{RejectMultiWord: true}, "", false},
+		{"don't", NormalizeOptions{StripPunctuation: true}, "dont", true},
+		{"r2-d2", NormalizeOptions{StripPunctuation: true, StripDigits: true}, "rd", true},
+		{"42", NormalizeOptions{StripDigits: true}, "", false},
+		{"año", NormalizeOptions{MinLength: 3, MaxLength: 3}, "año", true},
+		{"años", NormalizeOptions{MaxLength: 3}, "", false},
+		{"an", NormalizeOptions{MinLength: 3}, "", false},
+		{"   ", NormalizeOptions{}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeWord(tt.entry, tt.opts)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeWord(%q, %+v) = %q, %v, want %q, %v", tt.entry, tt.opts, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeWordsDropsDuplicates(t *testing.T) {
+	got := NormalizeWords([]string{"Résumé", "resume", "", "RESUME", "cv"}, NormalizeOptions{FoldDiacritics: true})
+	if !equalStringSlices(got, []string{"resume", "cv"}) {
+		t.Errorf("NormalizeWords() = %v, want [resume cv]", got)
+	}
+}
+
+func TestNormalizeWeightedWords(t *testing.T) {
+	entries := []string{"the\t1000", "Apple\t10", "aardwolf", "APPLE\t5", "ice cream\t100", "route 66x"}
+	words, frequencies := NormalizeWeightedWords(entries, NormalizeOptions{})
+	if !equalStringSlices(words, []string{"the", "apple", "aardwolf", "ice cream", "route 66x"}) {
+		t.Fatalf("NormalizeWeightedWords() = %v", words)
+	}
+	if frequencies["the"] != 1 || frequencies["aardwolf"] != 0 {
+		t.Errorf("Expected the commonest at 1 and words without counts at 0, got %v", frequencies)
+	}
+	if !(frequencies["the"] > frequencies["ice cream"] && frequencies["ice cream"] > frequencies["apple"] && frequencies["apple"] > 0) {
+		t.Errorf("Expected frequencies to follow the counts, got %v", frequencies)
+	}
+
+	// Ranks count down, and duplicates keep their best rank
+	_, frequencies = NormalizeWeightedWords([]string{"the\t1", "of\t2", "zebra\t900", "Of\t3"}, NormalizeOptions{Frequency: FrequencyRank})
+	if frequencies["the"] != 1 || !(frequencies["of"] > frequencies["zebra"]) || frequencies["zebra"] <= 0 {
+		t.Errorf("Expected frequencies to follow the ranks, got %v", frequencies)
+	}
+
+	if _, frequencies := NormalizeWeightedWords([]string{"apple", "banana"}, NormalizeOptions{}); frequencies != nil {
+		t.Errorf("Expected no frequencies without a column, got %v", frequencies)
+	}
+
+	// A number after a space is part of the phrase, not a frequency
+	words, frequencies = NormalizeWeightedWords([]string{"apollo 11", "catch 22", "banana"}, NormalizeOptions{})
+	if !equalStringSlices(words, []string{"apollo 11", "catch 22", "banana"}) || frequencies != nil {
+		t.Errorf("Expected phrases ending in numbers to stay whole and unweighted, got %v %v", words, frequencies)
+	}
+}
+
+func TestNormalizeOptionsValidate(t *testing.T) {
+	for _, opts := range []NormalizeOptions{{Form: "nfkc"}, {MinLength: -1}, {MinLength: 5, MaxLength: 3}, {Frequency: "percent"}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", opts)
+		}
+	}
+	if err := (NormalizeOptions{Form: NormalizeNFD, MinLength: 2, MaxLength: 8}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
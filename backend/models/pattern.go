@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxPatternLength bounds the length of a search pattern
+const MaxPatternLength = 64
+
+// Kinds of pattern tokens
+const (
+	patternLetter = iota // One given letter
+	patternAny           // '?', any one letter
+	patternStar          // '*', any run of letters, possibly empty
+	patternClass         // '[...]', one letter of a class
+)
+
+// patternToken is one element of a compiled pattern
+type patternToken struct {
+	kind    int
+	letter  rune
+	ranges  [][2]rune // Letter ranges of a class, inclusive
+	negated bool      // Set for '[^...]' classes
+}
+
+// matches reports whether a letter matches a single letter token
+func (p patternToken) matches(ch rune) bool {
+	switch p.kind {
+	case patternLetter:
+		return ch == p.letter
+	case patternAny:
+		return true
+	case patternClass:
+		in := false
+		for _, r := range p.ranges {
+			if ch >= r[0] && ch <= r[1] {
+				in = true
+				break
+			}
+		}
+		return in != p.negated
+	}
+	return false
+}
+
+// compilePattern parses a pattern where '?' stands for any letter, '*' for
+// any run of letters and '[...]' for one letter of a class, such as [aeiou],
+// [a-f] or [^aeiou]. Other characters stand for themselves.
+func compilePattern(pattern string) ([]patternToken, error) {
+	pattern = strings.ToLower(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	if utf8.RuneCountInString(pattern) > MaxPatternLength {
+		return nil, fmt.Errorf("pattern is longer than %d characters", MaxPatternLength)
+	}
+
+	runes := []rune(pattern)
+	var tokens []patternToken
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '?':
+			tokens = append(tokens, patternToken{kind: patternAny})
+		case '*':
+			// Consecutive stars match the same as one
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != patternStar {
+				tokens = append(tokens, patternToken{kind: patternStar})
+			}
+		case '[':
+			token, end, err := compileClass(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = end
+		case ']':
+			return nil, fmt.Errorf("unexpected ']' at %d", i)
+		default:
+			tokens = append(tokens, patternToken{kind: patternLetter, letter: runes[i]})
+		}
+	}
+	return tokens, nil
+}
+
+// compileClass parses the class opening at runes[start] and returns it with
+// the index of its closing bracket
+func compileClass(runes []rune, start int) (patternToken, int, error) {
+	token := patternToken{kind: patternClass}
+	i := start + 1
+	if i < len(runes) && runes[i] == '^' {
+		token.negated = true
+		i++
+	}
+	for ; i < len(runes) && runes[i] != ']'; i++ {
+		from, to := runes[i], runes[i]
+		if i+2 < len(runes) && runes[i+1] == '-' && runes[i+2] != ']' {
+			to = runes[i+2]
+			i += 2
+		}
+		if from > to {
+			return token, 0, fmt.Errorf("invalid range '%c-%c'", from, to)
+		}
+		token.ranges = append(token.ranges, [2]rune{from, to})
+	}
+	if i == len(runes) {
+		return token, 0, fmt.Errorf("unterminated class at %d", start)
+	}
+	if len(token.ranges) == 0 {
+		return token, 0, fmt.Errorf("empty class at %d", start)
+	}
+	return token, i, nil
+}
+
+// MatchPattern returns the words matching a pattern, sorted. The pattern is
+// matched against the trie node by node, so only the branches it allows are
+// visited. See compilePattern for the syntax.
+func (t *Trie) MatchPattern(pattern string) ([]string, error) {
+	return matchPattern(t.Root, pattern, "", 0)
+}
+
+// MatchPatternAfter returns up to limit words matching a pattern that sort
+// after cursor
+func (t *Trie) MatchPatternAfter(pattern, cursor string, limit int) ([]string, error) {
+	return matchPattern(t.Root, pattern, cursor, limit)
+}
+
+// matchPattern matches a pattern against the words of a graph in order,
+// only those after cursor unless it is empty, stopping once limit words are
+// found unless limit is 0. Every way of matching the letters walked so far
+// is followed at once, as the set of tokens matched, so each branch is
+// walked a single time and the words come out sorted.
+func matchPattern(root graphNode, pattern, cursor string, limit int) ([]string, error) {
+	tokens, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// A star can match nothing, so reaching it also reaches the next token
+	skipStars := func(matched []bool) []bool {
+		for i, token := range tokens {
+			if matched[i] && token.kind == patternStar {
+				matched[i+1] = true
+			}
+		}
+		return matched
+	}
+	// step follows a letter from the tokens matched, returning nil when no
+	// way of matching is left
+	step := func(matched []bool, ch rune) []bool {
+		var next []bool
+		for i, token := range tokens {
+			if !matched[i] {
+				continue
+			}
+			reached := i + 1
+			if token.kind == patternStar {
+				reached = i
+			} else if !token.matches(ch) {
+				continue
+			}
+			if next == nil {
+				next = make([]bool, len(tokens)+1)
+			}
+			next[reached] = true
+		}
+		if next == nil {
+			return nil
+		}
+		return skipStars(next)
+	}
+
+	results := []string{}
+	bound := []rune(cursor)
+	var collect func(node graphNode, word []rune, matched []bool, bounded bool)
+	collect = func(node graphNode, word []rune, matched []bool, bounded bool) {
+		if limit > 0 && len(results) >= limit {
+			return
+		}
+		if node.isWord() && matched[len(tokens)] && !bounded {
+			results = append(results, string(word))
+		}
+		node.eachChild(func(ch rune, child graphNode) {
+			// As in keysWithPrefix, the branches before the cursor are skipped
+			childBounded := false
+			switch {
+			case !bounded || len(word) == len(bound) || ch > bound[len(word)]:
+			case ch == bound[len(word)]:
+				childBounded = true
+			default:
+				return
+			}
+			if next := step(matched, ch); next != nil {
+				collect(child, append(word, ch), next, childBounded)
+			}
+		})
+	}
+	start := make([]bool, len(tokens)+1)
+	start[0] = true
+	collect(root, nil, skipStars(start), cursor != "")
+	return results, nil
+}
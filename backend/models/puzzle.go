@@ -0,0 +1,257 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"unicode/utf8"
+
+	utils "wordbuilder/utils"
+)
+
+// Puzzle difficulties, which pick the target length and where the seed sits
+const (
+	PuzzleEasy   = "easy"   // Short words, seeded with their first letter
+	PuzzleMedium = "medium" // Medium words, seeded with their first or last letter
+	PuzzleHard   = "hard"   // Long words, seeded from inside the word where possible
+)
+
+// PuzzleOptions selects the target word of a puzzle
+type PuzzleOptions struct {
+	Difficulty string
+	MinLength  int // 0 for the difficulty's default
+	MaxLength  int // 0 for the difficulty's default
+}
+
+// PuzzleState tracks a puzzle where the player builds a hidden target word
+// from a seed letter. The letter sets only offer letters that lead to the
+// target, so every move brings the answer one letter closer.
+type PuzzleState struct {
+	Target     string `json:"target"` // Never shown to the player until solved
+	Seed       string `json:"seed"`
+	Start      int    `json:"start"` // Rune offset of the answer inside the target
+	Difficulty string `json:"difficulty"`
+	MinLength  int    `json:"min_length"`
+	MaxLength  int    `json:"max_length"`
+	Solved     bool   `json:"solved"`
+}
+
+// puzzleLengths returns the default target lengths of a difficulty, 0 for no maximum
+func puzzleLengths(difficulty string) (int, int, bool) {
+	switch difficulty {
+	case PuzzleEasy:
+		return 3, 5, true
+	case PuzzleMedium:
+		return 5, 7, true
+	case PuzzleHard:
+		return 7, 0, true
+	}
+	return 0, 0, false
+}
+
+// GeneratePuzzle picks a target word and a seed letter from which the target
+// can be built. The choice only depends on rng and the dictionary, so the same
+// seed yields the same puzzle.
+func GeneratePuzzle(dict WordDictionaryI, opts PuzzleOptions, rng *rand.Rand) (PuzzleState, error) {
+	if opts.Difficulty == "" {
+		opts.Difficulty = PuzzleMedium
+	}
+	minLength, maxLength, ok := puzzleLengths(opts.Difficulty)
+	if !ok {
+		return PuzzleState{}, fmt.Errorf("invalid difficulty '%s'", opts.Difficulty)
+	}
+	if opts.MinLength > 0 {
+		minLength = opts.MinLength
+	}
+	if opts.MaxLength > 0 {
+		maxLength = opts.MaxLength
+	}
+	if maxLength > 0 && minLength > maxLength {
+		return PuzzleState{}, fmt.Errorf("min length %d is greater than max length %d", minLength, maxLength)
+	}
+
+	var candidates []string
+	for word := range dictWords(dict) {
+		length := utf8.RuneCountInString(word)
+		if length >= minLength && (maxLength == 0 || length <= maxLength) {
+			candidates = append(candidates, word)
+		}
+	}
+
+	for _, i := range rng.Perm(len(candidates)) {
+		target := candidates[i]
+		for _, seed := range puzzleSeeds(target, opts.Difficulty, rng) {
+			if !SolvePuzzle(dict, target, seed) {
+				continue
+			}
+			return PuzzleState{
+				Target:     target,
+				Seed:       string([]rune(target)[seed]),
+				Start:      seed,
+				Difficulty: opts.Difficulty,
+				MinLength:  opts.MinLength,
+				MaxLength:  opts.MaxLength,
+			}, nil
+		}
+	}
+	return PuzzleState{}, fmt.Errorf("no solvable puzzle with %d to %d letters", minLength, maxLength)
+}
+
+// puzzleSeeds lists the seed offsets to try for a target, best first
+func puzzleSeeds(target, difficulty string, rng *rand.Rand) []int {
+	n := utf8.RuneCountInString(target)
+	switch difficulty {
+	case PuzzleEasy:
+		return []int{0}
+	case PuzzleMedium:
+		if rng.Intn(2) == 0 {
+			return []int{0, n - 1}
+		}
+		return []int{n - 1, 0}
+	}
+
+	// Interior seeds first, falling back to the ends
+	seeds := make([]int, 0, n)
+	for _, i := range rng.Perm(n) {
+		if i != 0 && i != n-1 {
+			seeds = append(seeds, i)
+		}
+	}
+	return append(seeds, 0, n-1)
+}
+
+// StartPuzzle turns a fresh state into a puzzle, starting from its seed letter
+func StartPuzzle(state WordBuilderState, dict WordDictionaryI, puzzle PuzzleState) WordBuilderState {
+	state.Puzzle = &puzzle
+	state.Answer = puzzle.Seed
+	state.IsValidWord = CheckValidWord(state, dict)
+	return ConstrainToPuzzle(state, dict)
+}
+
+// PuzzleMove adds a letter toward the target of a puzzle
+func PuzzleMove(state WordBuilderState, dict WordDictionaryI, letter, position string) (WordBuilderState, string, error) {
+	if state.Puzzle == nil {
+		return state, "This session is not a puzzle.", fmt.Errorf("not a puzzle")
+	}
+	if state.Puzzle.Solved {
+		return state, "The puzzle is solved. Reset for a new one.", fmt.Errorf("puzzle solved")
+	}
+
+	newState, message, err := AddLetter(state, dict, letter, position)
+	if err != nil {
+		return state, message, err
+	}
+	puzzle := *state.Puzzle
+	if position == "prefix" {
+		puzzle.Start--
+	}
+	newState.Puzzle = &puzzle
+	newState = ConstrainToPuzzle(newState, dict)
+
+	// AddLetter's message lists completions, which would give the target away
+	message = fmt.Sprintf("Step %d: Added '%s' as %s -> Answer: %s", newState.Step, letter, position, newState.Answer)
+	if newState.Puzzle.Solved {
+		message += fmt.Sprintf("\nPuzzle solved: '%s'!", newState.Answer)
+	}
+	return newState, message, nil
+}
+
+// ConstrainToPuzzle narrows the letter sets to the letters that lead to the
+// target and hides the completions, which would give the target away
+func ConstrainToPuzzle(state WordBuilderState, dict WordDictionaryI) WordBuilderState {
+	puzzle := *state.Puzzle
+	target := []rune(puzzle.Target)
+	start, end := puzzle.Start, puzzle.Start+utf8.RuneCountInString(state.Answer)
+
+	state.PrefixSet = make(map[string]bool)
+	state.SuffixSet = make(map[string]bool)
+	state.GapSets = nil
+	state.ValidCompletions = []string{}
+
+	solver := newPuzzleSolver(dict, puzzle.Target)
+	if start > 0 && solver.canPrefix(start, end) && solver.reachable(start-1, end) {
+		state.PrefixSet[string(target[start-1])] = true
+	}
+	if end < len(target) && solver.canSuffix(start, end) && solver.reachable(start, end+1) {
+		state.SuffixSet[string(target[end])] = true
+	}
+
+	puzzle.Solved = state.Answer == puzzle.Target
+	state.Puzzle = &puzzle
+	return state
+}
+
+// GetPuzzleView returns the parts of a puzzle the player may see
+func GetPuzzleView(puzzle *PuzzleState) map[string]interface{} {
+	if puzzle == nil {
+		return nil
+	}
+	view := map[string]interface{}{
+		"difficulty": puzzle.Difficulty,
+		"seed":       puzzle.Seed,
+		"length":     utf8.RuneCountInString(puzzle.Target),
+		"solved":     puzzle.Solved,
+	}
+	if puzzle.Solved {
+		view["target"] = puzzle.Target
+	}
+	return view
+}
+
+// SolvePuzzle reports whether target can be built from the letter at seed by
+// adding letters to either end, where every step is allowed by the forward
+// and reverse tries
+func SolvePuzzle(dict WordDictionaryI, target string, seed int) bool {
+	if seed < 0 || seed >= utf8.RuneCountInString(target) {
+		return false
+	}
+	return newPuzzleSolver(dict, target).reachable(seed, seed+1)
+}
+
+// puzzleSolver searches the fragments target[i:j] on the way to the target
+type puzzleSolver struct {
+	dict   WordDictionaryI
+	target []rune
+	memo   map[[2]int]bool
+}
+
+func newPuzzleSolver(dict WordDictionaryI, target string) *puzzleSolver {
+	return &puzzleSolver{dict: dict, target: []rune(target), memo: make(map[[2]int]bool)}
+}
+
+// reachable reports whether the whole target can be built from target[i:j]
+func (p *puzzleSolver) reachable(i, j int) bool {
+	if i == 0 && j == len(p.target) {
+		return true
+	}
+	key := [2]int{i, j}
+	if result, seen := p.memo[key]; seen {
+		return result
+	}
+
+	result := (i > 0 && p.canPrefix(i, j) && p.reachable(i-1, j)) ||
+		(j < len(p.target) && p.canSuffix(i, j) && p.reachable(i, j+1))
+	p.memo[key] = result
+	return result
+}
+
+// canPrefix reports whether the reverse trie offers target[i-1] before target[i:j]
+func (p *puzzleSolver) canPrefix(i, j int) bool {
+	letter := string(p.target[i-1])
+	for _, next := range p.dict.GetReverseTrie().GetNextLetters(utils.ReverseString(string(p.target[i:j]))) {
+		if next == letter {
+			return true
+		}
+	}
+	return false
+}
+
+// canSuffix reports whether the forward trie offers target[j] after target[i:j]
+func (p *puzzleSolver) canSuffix(i, j int) bool {
+	letter := string(p.target[j])
+	for _, next := range p.dict.GetForwardTrie().GetNextLetters(string(p.target[i:j])) {
+		if next == letter {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSolvePuzzle(t *testing.T) {
+	tests := []struct {
+		words []string
+		seed  int
+		want  bool
+	}{
+		{[]string{"cart"}, 0, true},               // Suffixes all the way
+		{[]string{"cart"}, 3, true},               // Prefixes all the way
+		{[]string{"cart"}, 2, false},              // No word ends or starts with 'r'
+		{[]string{"cart", "car"}, 2, true},        // "r" -> "ar" -> "car" -> "cart"
+		{[]string{"cart", "car"}, 1, false},       // No word ends or starts with 'a'
+		{[]string{"cart", "car", "at"}, 1, false}, // "at" leads away from "cart"
+		{[]string{"cart", "car", "art"}, 1, true}, // "a" -> "ar" -> "car" -> "cart"
+		{[]string{"cart"}, 4, false},
+	}
+	for _, tt := range tests {
+		dict := NewWordDictionary(tt.words)
+		if got := SolvePuzzle(dict, "cart", tt.seed); got != tt.want {
+			t.Errorf("SolvePuzzle(%v, 'cart', %d) = %v, want %v", tt.words, tt.seed, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePuzzle(t *testing.T) {
+	dict := newTestDictionary()
+
+	puzzle, err := GeneratePuzzle(dict, PuzzleOptions{Difficulty: PuzzleEasy}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !dict.ContainsWord(puzzle.Target) || puzzle.Start != 0 || puzzle.Seed != puzzle.Target[:1] {
+		t.Errorf("Expected an easy puzzle seeded with the first letter, got %+v", puzzle)
+	}
+
+	// The same seed gives the same puzzle, and every puzzle is solvable
+	for seed := int64(0); seed < 20; seed++ {
+		first, err := GeneratePuzzle(dict, PuzzleOptions{Difficulty: PuzzleHard, MinLength: 4}, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		second, _ := GeneratePuzzle(dict, PuzzleOptions{Difficulty: PuzzleHard, MinLength: 4}, rand.New(rand.NewSource(seed)))
+		if first != second {
+			t.Errorf("Seed %d gave different puzzles: %+v and %+v", seed, first, second)
+		}
+		if len(first.Target) < 4 || !SolvePuzzle(dict, first.Target, first.Start) {
+			t.Errorf("Seed %d gave an invalid puzzle %+v", seed, first)
+		}
+	}
+
+	if _, err := GeneratePuzzle(dict, PuzzleOptions{Difficulty: "impossible"}, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for an unknown difficulty")
+	}
+	if _, err := GeneratePuzzle(dict, PuzzleOptions{MinLength: 50}, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error when no word is long enough")
+	}
+}
+
+func TestPuzzleMove(t *testing.T) {
+	dict := NewWordDictionary([]string{"cart", "car", "at", "art"})
+	state := StartPuzzle(UpdateSets(WordBuilderState{}, dict), dict, PuzzleState{Target: "cart", Seed: "a", Start: 1})
+
+	// From "a" only "art" leads on, while "at" doesn't grow into "cart"
+	if len(state.PrefixSet) != 0 || len(state.SuffixSet) != 1 || !state.SuffixSet["r"] {
+		t.Fatalf("Expected only 'r' as suffix, got %v and %v", state.PrefixSet, state.SuffixSet)
+	}
+	if len(state.ValidCompletions) != 0 {
+		t.Error("Completions would give the target away")
+	}
+	if _, _, err := PuzzleMove(state, dict, "t", "suffix"); err == nil {
+		t.Error("Expected an error for a letter leading away from the target")
+	}
+
+	state, _, _ = PuzzleMove(state, dict, "r", "suffix")
+	state, _, _ = PuzzleMove(state, dict, "c", "prefix")
+	if state.Puzzle.Solved || GetPuzzleView(state.Puzzle)["target"] != nil {
+		t.Error("The target must stay hidden until solved")
+	}
+	state, message, err := PuzzleMove(state, dict, "t", "suffix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", message)
+	}
+	if !state.Puzzle.Solved || state.Answer != "cart" || GetPuzzleView(state.Puzzle)["target"] != "cart" {
+		t.Errorf("Expected the puzzle solved, got %+v", state.Puzzle)
+	}
+	if _, _, err := PuzzleMove(state, dict, "s", "suffix"); err == nil {
+		t.Error("Expected an error moving after the puzzle is solved")
+	}
+}
+
+func TestPuzzleMoveHidesCompletions(t *testing.T) {
+	dict := NewWordDictionary([]string{"cart", "car"})
+	state := StartPuzzle(UpdateSets(WordBuilderState{}, dict), dict, PuzzleState{Target: "cart", Seed: "c", Start: 0})
+
+	_, message, err := PuzzleMove(state, dict, "a", "suffix")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", message)
+	}
+	if strings.Contains(message, "cart") {
+		t.Errorf("Message gives the target away: %q", message)
+	}
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bounds on the number of tiles in a rack
+const (
+	DefaultRackSize = 7
+	MaxRackSize     = 20
+)
+
+// MaxBagTiles bounds the tiles of a distribution, as the bag holds each one
+const MaxBagTiles = 1000
+
+// englishTiles is the number of tiles of each letter in an English Scrabble
+// set, without the blanks
+var englishTiles = map[string]int{
+	"a": 9, "b": 2, "c": 2, "d": 4, "e": 12, "f": 2, "g": 3, "h": 2, "i": 9,
+	"j": 1, "k": 1, "l": 4, "m": 2, "n": 6, "o": 8, "p": 2, "q": 1, "r": 6,
+	"s": 4, "t": 6, "u": 4, "v": 2, "w": 2, "x": 1, "y": 2, "z": 1,
+}
+
+// otherLetterTiles is the number of tiles of letters the English set lacks
+const otherLetterTiles = 2
+
+// RackState tracks a session where words are built from a finite rack of
+// letter tiles. The tiles on the answer are used up, so the letter sets only
+// offer tiles still left on the rack.
+type RackState struct {
+	Tiles        []string       `json:"tiles"`                  // The whole rack, sorted
+	Supplied     bool           `json:"supplied"`               // Set when the tiles were given rather than drawn
+	Distribution map[string]int `json:"distribution,omitempty"` // Tiles per letter to draw from, nil for the default
+}
+
+// DefaultDistribution returns the tiles of an English Scrabble set for the
+// letters of an alphabet, with a couple of tiles for any letter it lacks
+func DefaultDistribution(alphabet []string) map[string]int {
+	distribution := make(map[string]int, len(alphabet))
+	for _, letter := range alphabet {
+		if count, ok := englishTiles[letter]; ok {
+			distribution[letter] = count
+		} else {
+			distribution[letter] = otherLetterTiles
+		}
+	}
+	return distribution
+}
+
+// ValidateDistribution checks that a distribution has no negative counts
+// and at most MaxBagTiles tiles in all
+func ValidateDistribution(distribution map[string]int) error {
+	total := 0
+	for letter, count := range distribution {
+		if count < 0 {
+			return fmt.Errorf("negative tile count for '%s'", letter)
+		}
+		// Checked letter by letter so the total can't overflow
+		if count > MaxBagTiles || total+count > MaxBagTiles {
+			return fmt.Errorf("a distribution holds at most %d tiles", MaxBagTiles)
+		}
+		total += count
+	}
+	return nil
+}
+
+// DrawRack draws size tiles at random from a bag holding the distribution's
+// tiles. Only letters of the dictionary's alphabet can be drawn.
+func DrawRack(dict WordDictionaryI, distribution map[string]int, size int, rng *rand.Rand) ([]string, error) {
+	if size <= 0 || size > MaxRackSize {
+		return nil, fmt.Errorf("rack size must be between 1 and %d", MaxRackSize)
+	}
+	if distribution == nil {
+		distribution = DefaultDistribution(dict.GetAlphabet())
+	}
+	if err := ValidateDistribution(distribution); err != nil {
+		return nil, err
+	}
+
+	// Sorted so the same rng draws the same rack
+	letters := make([]string, 0, len(distribution))
+	for letter := range distribution {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+
+	var bag []string
+	for _, letter := range letters {
+		if distribution[letter] > 0 && !IsLetter(dict, letter) {
+			return nil, fmt.Errorf("'%s' is not a letter of this word list", letter)
+		}
+		for i := 0; i < distribution[letter]; i++ {
+			bag = append(bag, letter)
+		}
+	}
+	if len(bag) < size {
+		return nil, fmt.Errorf("the distribution only has %d tiles", len(bag))
+	}
+
+	rng.Shuffle(len(bag), func(i, j int) { bag[i], bag[j] = bag[j], bag[i] })
+	tiles := bag[:size]
+	sort.Strings(tiles)
+	return tiles, nil
+}
+
+// ParseRack splits supplied letters into tiles, checking each belongs to the
+// dictionary's alphabet
+func ParseRack(dict WordDictionaryI, letters string) ([]string, error) {
+	var tiles []string
+	for _, ch := range strings.ToLower(letters) {
+		letter := string(ch)
+		if !IsLetter(dict, letter) {
+			return nil, fmt.Errorf("'%s' is not a letter of this word list", letter)
+		}
+		tiles = append(tiles, letter)
+	}
+	if len(tiles) == 0 || len(tiles) > MaxRackSize {
+		return nil, fmt.Errorf("rack size must be between 1 and %d", MaxRackSize)
+	}
+	sort.Strings(tiles)
+	return tiles, nil
+}
+
+// StartRack turns a fresh state into a session on a rack of tiles
+func StartRack(state WordBuilderState, rack RackState) WordBuilderState {
+	state.Rack = &rack
+	return ConstrainToRack(state)
+}
+
+// Remaining returns the tiles not used by answer, or false if answer uses
+// tiles the rack doesn't have
+func (r *RackState) Remaining(answer string) ([]string, bool) {
+	counts := tileCounts(r.Tiles)
+	for _, ch := range answer {
+		if counts[string(ch)] == 0 {
+			return nil, false
+		}
+		counts[string(ch)]--
+	}
+
+	remaining := make([]string, 0, len(r.Tiles))
+	for _, tile := range r.Tiles {
+		if counts[tile] > 0 {
+			remaining = append(remaining, tile)
+			counts[tile]--
+		}
+	}
+	return remaining, true
+}
+
+// ConstrainToRack narrows the letter sets to the tiles left on the rack and
+// drops the completions the rack can't build
+func ConstrainToRack(state WordBuilderState) WordBuilderState {
+	remaining, _ := state.Rack.Remaining(state.Answer)
+	left := tileCounts(remaining)
+
+	state.PrefixSet = intersectTiles(state.PrefixSet, left)
+	state.SuffixSet = intersectTiles(state.SuffixSet, left)
+	gaps := make([]map[string]bool, len(state.GapSets))
+	for i, gap := range state.GapSets {
+		gaps[i] = intersectTiles(gap, left)
+	}
+	state.GapSets = gaps
+
+	state.ValidCompletions = append([]string{}, rackWords(state.Rack, state.ValidCompletions)...)
+	return state
+}
+
+// rackWords keeps the words that can be built from the rack
+func rackWords(rack *RackState, words []string) []string {
+	var buildable []string
+	for _, word := range words {
+		if _, ok := rack.Remaining(word); ok {
+			buildable = append(buildable, word)
+		}
+	}
+	return buildable
+}
+
+// intersectTiles keeps the letters of set that are among the tiles
+func intersectTiles(set map[string]bool, tiles map[string]int) map[string]bool {
+	result := make(map[string]bool)
+	for letter := range set {
+		if tiles[letter] > 0 {
+			result[letter] = true
+		}
+	}
+	return result
+}
+
+// tileCounts counts the tiles of each letter
+func tileCounts(tiles []string) map[string]int {
+	counts := make(map[string]int, len(tiles))
+	for _, tile := range tiles {
+		counts[tile]++
+	}
+	return counts
+}
+
+// SolveRack lists the words that can be built from the tiles, best first.
+// Words are ranked by score, then by length; a nil scorer ranks by length
+// alone. limit bounds the number of words returned, 0 for no limit.
+func SolveRack(dict *WordDictionary, tiles []string, scorer Scorer, limit int) []WordScore {
+	var words []WordScore
+	for _, match := range dict.SubAnagrams([]rune(strings.Join(tiles, "")), 0, 1) {
+		score := WordScore{Word: match.Word, Total: utf8.RuneCountInString(match.Word)}
+		if scorer != nil {
+			score = scorer.ScoreWord(match.Word, dict)
+		}
+		words = append(words, score)
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Total != words[j].Total {
+			return words[i].Total > words[j].Total
+		}
+		li, lj := len([]rune(words[i].Word)), len([]rune(words[j].Word))
+		if li != lj {
+			return li > lj
+		}
+		return words[i].Word < words[j].Word
+	})
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+	return words
+}
+
+// GetRackView formats the rack for the API, with the tiles the answer leaves
+func GetRackView(rack *RackState, answer string) map[string]interface{} {
+	if rack == nil {
+		return nil
+	}
+	remaining, _ := rack.Remaining(answer)
+	if remaining == nil {
+		remaining = []string{}
+	}
+	return map[string]interface{}{
+		"tiles":     rack.Tiles,
+		"remaining": remaining,
+		"supplied":  rack.Supplied,
+	}
+}
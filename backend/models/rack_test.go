@@ -0,0 +1,110 @@
+package models
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDrawRack(t *testing.T) {
+	dict := newTestDictionary()
+
+	tiles, err := DrawRack(dict, nil, 7, rand.New(rand.NewSource(1)))
+	if err != nil || len(tiles) != 7 {
+		t.Fatalf("Expected 7 tiles, got %v: %v", tiles, err)
+	}
+	for _, tile := range tiles {
+		if !IsLetter(dict, tile) {
+			t.Errorf("Drew '%s', which is not in the alphabet", tile)
+		}
+	}
+	again, _ := DrawRack(dict, nil, 7, rand.New(rand.NewSource(1)))
+	if !equalStringSlices(tiles, again) {
+		t.Errorf("Expected the same seed to draw the same rack, got %v and %v", tiles, again)
+	}
+
+	tiles, err = DrawRack(dict, map[string]int{"a": 2, "n": 1}, 3, rand.New(rand.NewSource(1)))
+	if err != nil || !equalStringSlices(tiles, []string{"a", "a", "n"}) {
+		t.Errorf("Expected the whole bag, got %v: %v", tiles, err)
+	}
+	if _, err := DrawRack(dict, map[string]int{"a": 2}, 3, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error when the bag runs out of tiles")
+	}
+	if _, err := DrawRack(dict, map[string]int{"z": 5}, 3, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for tiles outside the alphabet")
+	}
+	if _, err := DrawRack(dict, nil, MaxRackSize+1, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for an oversized rack")
+	}
+	if _, err := DrawRack(dict, map[string]int{"a": 1000000000}, 3, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for a bag of more than MaxBagTiles tiles")
+	}
+	if _, err := DrawRack(dict, map[string]int{"a": 900, "b": 900}, 3, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected the tiles of all letters to count toward MaxBagTiles")
+	}
+	if _, err := DrawRack(dict, map[string]int{"a": 5, "b": -1}, 3, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for a negative count")
+	}
+}
+
+func TestParseRack(t *testing.T) {
+	dict := newTestDictionary()
+
+	tiles, err := ParseRack(dict, "NaBd")
+	if err != nil || !equalStringSlices(tiles, []string{"a", "b", "d", "n"}) {
+		t.Errorf("Expected sorted tiles, got %v: %v", tiles, err)
+	}
+	if _, err := ParseRack(dict, "abz"); err == nil {
+		t.Error("Expected an error for a letter outside the alphabet")
+	}
+	if _, err := ParseRack(dict, ""); err == nil {
+		t.Error("Expected an error for an empty rack")
+	}
+}
+
+func TestRackConstrainsMoves(t *testing.T) {
+	dict := newTestDictionary()
+	state := StartRack(UpdateSets(WordBuilderState{}, dict), RackState{Tiles: []string{"a", "b", "d", "n"}, Supplied: true})
+
+	if state.PrefixSet["c"] || state.PrefixSet["e"] {
+		t.Errorf("Expected only rack letters, got %v", state.PrefixSet)
+	}
+	state, _, err := AddLetter(state, dict, "b", "prefix")
+	if err != nil {
+		t.Fatalf("Failed to add 'b': %v", err)
+	}
+	state = ConstrainToRack(state)
+	if state.SuffixSet["b"] {
+		t.Error("Expected the only 'b' tile to be used up")
+	}
+	for _, word := range state.ValidCompletions {
+		if word != "band" {
+			t.Errorf("Completion '%s' can't be built from the rack", word)
+		}
+	}
+
+	remaining, ok := state.Rack.Remaining(state.Answer)
+	if !ok || !equalStringSlices(remaining, []string{"a", "d", "n"}) {
+		t.Errorf("Expected a, d and n left, got %v", remaining)
+	}
+	if _, ok := state.Rack.Remaining("banana"); ok {
+		t.Error("Expected 'banana' to need more tiles than the rack has")
+	}
+}
+
+func TestSolveRack(t *testing.T) {
+	dict := newTestDictionary()
+
+	words := SolveRack(dict, []string{"a", "a", "b", "d", "n", "n"}, nil, 0)
+	var found []string
+	for _, word := range words {
+		found = append(found, word.Word)
+	}
+	if !equalStringSlices(found, []string{"band"}) {
+		t.Errorf("Expected only 'band', got %v", found)
+	}
+
+	words = SolveRack(dict, []string{"a", "a", "a", "b", "c", "d", "n", "n", "n"}, NewDefaultScorer(), 2)
+	if len(words) != 2 || words[0].Word != "bandana" || words[0].Total < words[1].Total {
+		t.Errorf("Expected 'bandana' first of two, got %+v", words)
+	}
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"fmt"
+)
+
+// RoomPlayer is a seat in a shared room, held by the session ID the player
+// connects with so a dropped connection can take it back
+type RoomPlayer struct {
+	SessionID  string
+	Name       string
+	Connected  bool
+	Eliminated bool
+}
+
+// RoomState is a game several players share.
+//
+// Players take turns adding a letter to either end of a common answer. A
+// player who completes a word of GhostMinWordLength letters or more, or who
+// leaves no letter to add, is eliminated and the next round starts from an
+// empty answer. The last player standing wins.
+type RoomState struct {
+	Game    WordBuilderState
+	Players []RoomPlayer // In turn order
+	Turn    int          // Seat of the player to move
+	Round   int
+	Started bool
+	Winner  int // Seat of the winner, -1 while the game is on
+}
+
+// NewRoomState creates a room waiting for players around a fresh game
+func NewRoomState(game WordBuilderState) RoomState {
+	return RoomState{Game: hideCompletions(game), Players: []RoomPlayer{}, Round: 1, Winner: -1}
+}
+
+// JoinRoom seats a player, or reconnects them if the session already holds a
+// seat, and returns the seat
+func JoinRoom(room RoomState, sessionID, name string) (RoomState, int, error) {
+	if seat := room.SeatOf(sessionID); seat >= 0 {
+		room.Players = copyPlayers(room.Players)
+		room.Players[seat].Connected = true
+		if name != "" {
+			room.Players[seat].Name = name
+		}
+		// Nobody could move while everyone was away
+		if !room.Players[room.Turn].Connected {
+			room.Turn = room.nextTurn(room.Turn)
+		}
+		return room, seat, nil
+	}
+	if room.Started {
+		return room, -1, fmt.Errorf("the game has already started")
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("Player %d", len(room.Players)+1)
+	}
+	room.Players = append(copyPlayers(room.Players), RoomPlayer{SessionID: sessionID, Name: name, Connected: true})
+	return room, len(room.Players) - 1, nil
+}
+
+// LeaveRoom marks a player as disconnected. Before the game starts the seat
+// is given up; afterwards it is kept for the player to reconnect, and their
+// turn passes to the next connected player.
+func LeaveRoom(room RoomState, sessionID string) RoomState {
+	seat := room.SeatOf(sessionID)
+	if seat < 0 {
+		return room
+	}
+
+	if !room.Started {
+		players := make([]RoomPlayer, 0, len(room.Players)-1)
+		players = append(players, room.Players[:seat]...)
+		room.Players = append(players, room.Players[seat+1:]...)
+		return room
+	}
+
+	room.Players = copyPlayers(room.Players)
+	room.Players[seat].Connected = false
+	if room.Turn == seat && room.Winner < 0 {
+		room.Turn = room.nextTurn(seat)
+	}
+	return room
+}
+
+// StartRoom begins the game once at least two players are seated
+func StartRoom(room RoomState, sessionID string) (RoomState, string, error) {
+	if room.SeatOf(sessionID) < 0 {
+		return room, "Only seated players can start the game.", fmt.Errorf("not seated")
+	}
+	if room.Started {
+		return room, "The game has already started.", fmt.Errorf("already started")
+	}
+	if len(room.Players) < 2 {
+		return room, "At least two players are needed to start.", fmt.Errorf("not enough players")
+	}
+
+	room.Started = true
+	room.Turn = 0
+	if !room.Players[0].Connected {
+		room.Turn = room.nextTurn(0)
+	}
+	return room, fmt.Sprintf("The game has started. %s goes first.", room.Players[room.Turn].Name), nil
+}
+
+// RoomMove adds a letter for the player whose turn it is
+func RoomMove(room RoomState, dict WordDictionaryI, sessionID, letter, position string) (RoomState, string, error) {
+	seat := room.SeatOf(sessionID)
+	switch {
+	case seat < 0:
+		return room, "Only seated players can move.", fmt.Errorf("not seated")
+	case !room.Started:
+		return room, "The game hasn't started yet.", fmt.Errorf("not started")
+	case room.Winner >= 0:
+		return room, "The game is over.", fmt.Errorf("game over")
+	case seat != room.Turn:
+		return room, fmt.Sprintf("It's %s's turn.", room.Players[room.Turn].Name), fmt.Errorf("not your turn")
+	}
+
+	game, message, err := AddLetter(room.Game, dict, letter, position)
+	if err != nil {
+		return room, message, err
+	}
+	// Every player sees the move, so it mustn't tell which words fit
+	message = fmt.Sprintf("Step %d: Added '%s' as %s -> Answer: %s", game.Step, letter, position, game.Answer)
+	room.Game = hideCompletions(game)
+	player := room.Players[seat].Name
+
+	var reason string
+	if isGhostLoss(dict, game.Answer) {
+		reason = fmt.Sprintf("%s completed the word '%s'", player, game.Answer)
+	} else if len(game.PrefixSet) == 0 && len(game.SuffixSet) == 0 {
+		reason = fmt.Sprintf("%s left no letter to add to '%s'", player, game.Answer)
+	}
+	if reason == "" {
+		room.Turn = room.nextTurn(seat)
+		return room, fmt.Sprintf("%s: %s", player, message), nil
+	}
+
+	room.Players = copyPlayers(room.Players)
+	room.Players[seat].Eliminated = true
+	if winner := room.lastStanding(); winner >= 0 {
+		room.Winner = winner
+		return room, fmt.Sprintf("%s and is eliminated. %s wins!", reason, room.Players[winner].Name), nil
+	}
+
+	room.Game = newRound(room.Game, dict)
+	room.Round++
+	room.Turn = room.nextTurn(seat)
+	return room, fmt.Sprintf("%s and is eliminated. Round %d starts with %s.", reason, room.Round, room.Players[room.Turn].Name), nil
+}
+
+// GetRoomView formats a room for the players, leaving out session IDs and
+// the completions of the answer
+func GetRoomView(room RoomState) map[string]interface{} {
+	players := make([]map[string]interface{}, len(room.Players))
+	for i, p := range room.Players {
+		players[i] = map[string]interface{}{
+			"seat":       i,
+			"name":       p.Name,
+			"connected":  p.Connected,
+			"eliminated": p.Eliminated,
+		}
+	}
+
+	return map[string]interface{}{
+		"players": players,
+		"turn":    room.Turn,
+		"round":   room.Round,
+		"started": room.Started,
+		"over":    room.Winner >= 0,
+		"winner":  room.Winner,
+		"state":   GetCurrentState(hideCompletions(room.Game)),
+	}
+}
+
+// SeatOf returns the seat held by a session, or -1
+func (room RoomState) SeatOf(sessionID string) int {
+	for i, p := range room.Players {
+		if p.SessionID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextTurn returns the next seat after from that is still in the game,
+// preferring connected players
+func (room RoomState) nextTurn(from int) int {
+	n := len(room.Players)
+	fallback := -1
+	for i := 1; i <= n; i++ {
+		seat := (from + i) % n
+		if room.Players[seat].Eliminated {
+			continue
+		}
+		if room.Players[seat].Connected {
+			return seat
+		}
+		if fallback < 0 {
+			fallback = seat
+		}
+	}
+	if fallback < 0 {
+		return from
+	}
+	return fallback
+}
+
+// lastStanding returns the only seat not eliminated, or -1
+func (room RoomState) lastStanding() int {
+	winner := -1
+	for i, p := range room.Players {
+		if p.Eliminated {
+			continue
+		}
+		if winner >= 0 {
+			return -1
+		}
+		winner = i
+	}
+	return winner
+}
+
+// newRound clears the answer for the next round, keeping the step count
+func newRound(game WordBuilderState, dict WordDictionaryI) WordBuilderState {
+	game.Answer = ""
+	game.IsValidWord = false
+	game.ValidCompletions = []string{}
+	game.History = nil
+	game.Undone = nil
+	return hideCompletions(UpdateSets(game, dict))
+}
+
+// copyPlayers copies the seats so earlier room states sharing them stay intact
+func copyPlayers(players []RoomPlayer) []RoomPlayer {
+	copied := make([]RoomPlayer, len(players))
+	copy(copied, players)
+	return copied
+}
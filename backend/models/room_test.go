@@ -0,0 +1,127 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestRoom(t *testing.T, players ...string) (RoomState, *WordDictionary) {
+	t.Helper()
+	dict := NewWordDictionary([]string{"cart", "cabin"})
+	room := NewRoomState(UpdateSets(WordBuilderState{}, dict))
+	for _, p := range players {
+		var err error
+		if room, _, err = JoinRoom(room, p, p); err != nil {
+			t.Fatalf("Failed to join %s: %v", p, err)
+		}
+	}
+	return room, dict
+}
+
+func mustMove(t *testing.T, room RoomState, dict WordDictionaryI, player, letter, position string) RoomState {
+	t.Helper()
+	room, message, err := RoomMove(room, dict, player, letter, position)
+	if err != nil {
+		t.Fatalf("%s adding '%s' failed: %s", player, letter, message)
+	}
+	return room
+}
+
+func TestRoomEliminations(t *testing.T) {
+	room, dict := newTestRoom(t, "ann", "bob", "cid")
+	if _, _, err := RoomMove(room, dict, "ann", "c", "prefix"); err == nil {
+		t.Error("Expected an error moving before the game starts")
+	}
+
+	room, _, err := StartRoom(room, "ann")
+	if err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if _, _, err := RoomMove(room, dict, "bob", "c", "prefix"); err == nil {
+		t.Error("Expected an error moving out of turn")
+	}
+
+	room = mustMove(t, room, dict, "ann", "c", "prefix")
+	room = mustMove(t, room, dict, "bob", "a", "suffix")
+	room = mustMove(t, room, dict, "cid", "r", "suffix")
+	room = mustMove(t, room, dict, "ann", "t", "suffix")
+
+	// Completing "cart" knocks ann out and starts a new round with bob
+	if !room.Players[0].Eliminated || room.Round != 2 || room.Game.Answer != "" || room.Turn != 1 {
+		t.Fatalf("Expected ann out and round 2 starting with bob, got %+v", room)
+	}
+
+	room = mustMove(t, room, dict, "bob", "c", "prefix")
+	room = mustMove(t, room, dict, "cid", "a", "suffix")
+	room = mustMove(t, room, dict, "bob", "b", "suffix")
+	room = mustMove(t, room, dict, "cid", "i", "suffix")
+	room = mustMove(t, room, dict, "bob", "n", "suffix")
+
+	if room.Winner != 2 {
+		t.Errorf("Expected cid to win, got winner %d", room.Winner)
+	}
+	if _, _, err := RoomMove(room, dict, "cid", "c", "prefix"); err == nil {
+		t.Error("Expected an error moving after the game is over")
+	}
+}
+
+func TestRoomJoinAndLeave(t *testing.T) {
+	room, _ := newTestRoom(t, "ann")
+	if _, _, err := StartRoom(room, "ann"); err == nil {
+		t.Error("Expected an error starting with a single player")
+	}
+
+	// Before the game starts, leaving gives up the seat
+	room, _, _ = JoinRoom(room, "bob", "")
+	if room.Players[1].Name != "Player 2" {
+		t.Errorf("Expected a default name, got %q", room.Players[1].Name)
+	}
+	room = LeaveRoom(room, "bob")
+	if len(room.Players) != 1 {
+		t.Fatalf("Expected 1 player after leaving, got %d", len(room.Players))
+	}
+
+	room, _, _ = JoinRoom(room, "bob", "bob")
+	room, _, _ = StartRoom(room, "bob")
+	if _, _, err := JoinRoom(room, "cid", "cid"); err == nil {
+		t.Error("Expected an error joining a started game")
+	}
+
+	// Afterwards the seat is kept and the turn moves on
+	room = LeaveRoom(room, "ann")
+	if room.Players[0].Connected || room.Turn != 1 {
+		t.Errorf("Expected ann disconnected and bob to move, got %+v", room)
+	}
+	room, seat, err := JoinRoom(room, "ann", "")
+	if err != nil || seat != 0 || !room.Players[0].Connected || room.Players[0].Name != "ann" {
+		t.Errorf("Expected ann back in seat 0, got seat %d, %+v, %v", seat, room.Players[0], err)
+	}
+}
+
+func TestRoomViewHidesSessions(t *testing.T) {
+	room, _ := newTestRoom(t, "ann")
+	view := GetRoomView(room)
+	players := view["players"].([]map[string]interface{})
+	if _, exists := players[0]["session_id"]; exists {
+		t.Error("The view must not reveal session IDs")
+	}
+}
+
+func TestRoomHidesCompletions(t *testing.T) {
+	room, dict := newTestRoom(t, "ann", "bob")
+	room, _, _ = StartRoom(room, "ann")
+	room, message, err := RoomMove(room, dict, "ann", "c", "prefix")
+	if err != nil {
+		t.Fatalf("Move failed: %s", message)
+	}
+	if strings.Contains(message, "cart") || strings.Contains(message, "cabin") {
+		t.Errorf("The broadcast message must not reveal completions, got %q", message)
+	}
+	if len(room.Game.ValidCompletions) != 0 || room.Game.GapSets != nil {
+		t.Errorf("Expected no completions in the room, got %v", room.Game.ValidCompletions)
+	}
+	state := GetRoomView(room)["state"].(map[string]interface{})
+	if completions, _ := state["valid_completions"].([]string); len(completions) != 0 {
+		t.Errorf("The room view must not reveal completions, got %v", completions)
+	}
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Actions that cost points
+const (
+	PenaltyRemove = "remove"
+	PenaltyHint   = "hint"
+)
+
+// Scorer decides how many points words are worth and what actions cost.
+// Sessions keep whatever scorer they are played with, so the scoring rules
+// can be swapped without touching the game logic.
+type Scorer interface {
+	ScoreWord(word string, dict WordDictionaryI) WordScore
+	Penalty(action string) int
+}
+
+// WordFrequencyI is implemented by dictionaries that know how common their
+// words are. Frequencies range from 0 for the rarest to 1 for the most common.
+type WordFrequencyI interface {
+	WordFrequency(word string) (float64, bool)
+}
+
+// WordScore breaks down the points a word earned
+type WordScore struct {
+	Word         string `json:"word"`
+	LetterPoints int    `json:"letter_points"`
+	LengthBonus  int    `json:"length_bonus"`
+	RarityBonus  int    `json:"rarity_bonus"`
+	Total        int    `json:"total"`
+}
+
+// ScoreState accumulates the score of a session across words
+type ScoreState struct {
+	Total     int         `json:"total"`
+	Words     []WordScore `json:"words"` // Words scored so far, each counted once
+	Removals  int         `json:"removals"`
+	Hints     int         `json:"hints"`
+	Penalties int         `json:"penalties"` // Points lost to removals and hints
+}
+
+// DefaultScorer scores words by letter values with bonuses for long and rare
+// words
+type DefaultScorer struct {
+	LetterValues    map[rune]int // Letters missing from the map are worth 1
+	LengthBonusFrom int          // Words at least this long earn the length bonus
+	LengthBonus     int          // Points per letter from LengthBonusFrom on
+	RarityBonus     int          // Points for the rarest words, scaled down for common ones
+	RemovePenalty   int
+	HintPenalty     int
+}
+
+// NewDefaultScorer creates a scorer with Scrabble letter values
+func NewDefaultScorer() *DefaultScorer {
+	values := make(map[rune]int)
+	for letters, value := range map[string]int{
+		"aeilnorstu": 1,
+		"dg":         2,
+		"bcmp":       3,
+		"fhvwy":      4,
+		"k":          5,
+		"jx":         8,
+		"qz":         10,
+	} {
+		for _, ch := range letters {
+			values[ch] = value
+		}
+	}
+
+	return &DefaultScorer{
+		LetterValues:    values,
+		LengthBonusFrom: 5,
+		LengthBonus:     2,
+		RarityBonus:     10,
+		RemovePenalty:   1,
+		HintPenalty:     3,
+	}
+}
+
+// ScoreWord scores a valid word
+func (s *DefaultScorer) ScoreWord(word string, dict WordDictionaryI) WordScore {
+	score := WordScore{Word: word}
+	for _, ch := range word {
+		if value, ok := s.LetterValues[ch]; ok {
+			score.LetterPoints += value
+		} else {
+			score.LetterPoints++
+		}
+	}
+
+	if length := utf8.RuneCountInString(word); s.LengthBonusFrom > 0 && length >= s.LengthBonusFrom {
+		score.LengthBonus = (length - s.LengthBonusFrom + 1) * s.LengthBonus
+	}
+
+	// Dictionaries without frequencies give no rarity bonus
+	if frequencies, ok := dict.(WordFrequencyI); ok {
+		if frequency, ok := frequencies.WordFrequency(word); ok {
+			frequency = math.Max(0, math.Min(1, frequency))
+			score.RarityBonus = int(math.Round(float64(s.RarityBonus) * (1 - frequency)))
+		}
+	}
+
+	score.Total = score.LetterPoints + score.LengthBonus + score.RarityBonus
+	return score
+}
+
+// Penalty returns the points an action costs
+func (s *DefaultScorer) Penalty(action string) int {
+	switch action {
+	case PenaltyRemove:
+		return s.RemovePenalty
+	case PenaltyHint:
+		return s.HintPenalty
+	}
+	return 0
+}
+
+// ScoreAnswer adds the answer to the score if it is a valid word that hasn't
+// been scored yet, and returns a message about the points earned
+func ScoreAnswer(state WordBuilderState, dict WordDictionaryI, scorer Scorer) (WordBuilderState, string) {
+	if !state.IsValidWord || state.Score.hasWord(state.Answer) {
+		return state, ""
+	}
+
+	score := scorer.ScoreWord(state.Answer, dict)
+	words := make([]WordScore, len(state.Score.Words), len(state.Score.Words)+1)
+	copy(words, state.Score.Words)
+	state.Score.Words = append(words, score)
+	state.Score.Total += score.Total
+	return state, fmt.Sprintf("+%d points for '%s' (total %d)", score.Total, score.Word, state.Score.Total)
+}
+
+// ApplyPenalty deducts the cost of an action from the score
+func ApplyPenalty(state WordBuilderState, scorer Scorer, action string) WordBuilderState {
+	penalty := scorer.Penalty(action)
+	switch action {
+	case PenaltyRemove:
+		state.Score.Removals++
+	case PenaltyHint:
+		state.Score.Hints++
+	}
+	state.Score.Penalties += penalty
+	state.Score.Total -= penalty
+	return state
+}
+
+// GetScoreView formats the score for the API
+func GetScoreView(score ScoreState) map[string]interface{} {
+	words := score.Words
+	if words == nil {
+		words = []WordScore{}
+	}
+	return map[string]interface{}{
+		"total":     score.Total,
+		"words":     words,
+		"removals":  score.Removals,
+		"hints":     score.Hints,
+		"penalties": score.Penalties,
+	}
+}
+
+// hasWord reports whether word was already scored
+func (score ScoreState) hasWord(word string) bool {
+	for _, scored := range score.Words {
+		if scored.Word == word {
+			return true
+		}
+	}
+	return false
+}
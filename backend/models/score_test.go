@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+)
+
+// frequencyDictionary adds word frequencies to a dictionary
+type frequencyDictionary struct {
+	*WordDictionary
+	frequencies map[string]float64
+}
+
+func (d frequencyDictionary) WordFrequency(word string) (float64, bool) {
+	frequency, ok := d.frequencies[word]
+	return frequency, ok
+}
+
+func TestDefaultScorer(t *testing.T) {
+	scorer := NewDefaultScorer()
+	dict := NewWordDictionary([]string{"cat", "quartz"})
+
+	tests := []struct {
+		word  string
+		score WordScore
+	}{
+		{"cat", WordScore{Word: "cat", LetterPoints: 5, Total: 5}},
+		{"quartz", WordScore{Word: "quartz", LetterPoints: 24, LengthBonus: 4, Total: 28}},
+	}
+	for _, tt := range tests {
+		if got := scorer.ScoreWord(tt.word, dict); got != tt.score {
+			t.Errorf("ScoreWord(%q) = %+v, want %+v", tt.word, got, tt.score)
+		}
+	}
+
+	// Rare words earn more than common ones
+	withFrequencies := frequencyDictionary{dict, map[string]float64{"cat": 0.9, "quartz": 0.1}}
+	if got := scorer.ScoreWord("cat", withFrequencies).RarityBonus; got != 1 {
+		t.Errorf("Expected a rarity bonus of 1 for 'cat', got %d", got)
+	}
+	if got := scorer.ScoreWord("quartz", withFrequencies).RarityBonus; got != 9 {
+		t.Errorf("Expected a rarity bonus of 9 for 'quartz', got %d", got)
+	}
+}
+
+func TestScoreAnswerCountsWordsOnce(t *testing.T) {
+	scorer := NewDefaultScorer()
+	dict := NewWordDictionary([]string{"cat"})
+	state := WordBuilderState{Answer: "cat", IsValidWord: true}
+
+	state, message := ScoreAnswer(state, dict, scorer)
+	if state.Score.Total != 5 || len(state.Score.Words) != 1 || message == "" {
+		t.Fatalf("Expected 'cat' scored for 5 points, got %+v", state.Score)
+	}
+	state, message = ScoreAnswer(state, dict, scorer)
+	if state.Score.Total != 5 || len(state.Score.Words) != 1 || message != "" {
+		t.Errorf("Expected 'cat' not to be scored twice, got %+v", state.Score)
+	}
+
+	state.Answer, state.IsValidWord = "ca", false
+	if scored, _ := ScoreAnswer(state, dict, scorer); len(scored.Score.Words) != 1 {
+		t.Error("Expected fragments not to be scored")
+	}
+}
+
+func TestApplyPenalty(t *testing.T) {
+	scorer := NewDefaultScorer()
+	state := WordBuilderState{Score: ScoreState{Total: 10}}
+
+	state = ApplyPenalty(state, scorer, PenaltyRemove)
+	state = ApplyPenalty(state, scorer, PenaltyHint)
+	want := ScoreState{Total: 6, Removals: 1, Hints: 1, Penalties: 4}
+	if state.Score.Total != want.Total || state.Score.Removals != want.Removals ||
+		state.Score.Hints != want.Hints || state.Score.Penalties != want.Penalties {
+		t.Errorf("Expected %+v, got %+v", want, state.Score)
+	}
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// SnapshotVersion is the version of the snapshot format written. Snapshots
+// of any other version are rebuilt rather than read.
+const SnapshotVersion = 2
+
+// snapshotMagic starts every snapshot file
+const snapshotMagic = "WBDS"
+
+// Flags of a snapshot
+const (
+	snapshotCompact  = 1 << 0 // The graphs are DAWGs rather than tries
+	snapshotWeighted = 1 << 1 // Each word is followed by its frequency
+)
+
+var (
+	// ErrSnapshotStale is returned for a snapshot taken of other words or
+	// with other options than the ones asked for
+	ErrSnapshotStale = errors.New("snapshot is stale")
+	// ErrSnapshotCorrupt is returned for a snapshot that can't be read back
+	ErrSnapshotCorrupt = errors.New("snapshot is corrupt")
+)
+
+// WriteSnapshot writes a dictionary in a binary form that loads without
+// rebuilding its tries. key identifies the words and options the dictionary
+// was built from, so ReadSnapshot can tell when the snapshot is out of date.
+//
+// A snapshot is the magic, the version as a little-endian uint16, the length
+// of the payload as a uint64, the payload and its CRC-32. The payload holds
+// the key, the flags, the words with their frequencies if the dictionary
+// knows them, and the forward and reverse graphs as tables of nodes, children
+// before their parents and the root last.
+func WriteSnapshot(w io.Writer, d *WordDictionary, key string) error {
+	var flags byte
+	if _, compact := d.ForwardTrie.(*DAWG); compact {
+		flags |= snapshotCompact
+	}
+	if d.weighted {
+		flags |= snapshotWeighted
+	}
+
+	payload := appendString(nil, key)
+	payload = append(payload, flags)
+	words := d.GetWordList()
+	payload = binary.AppendUvarint(payload, uint64(len(words)))
+	for _, word := range words {
+		payload = appendString(payload, word)
+		if d.weighted {
+			frequency, _ := d.WordFrequency(word)
+			payload = binary.LittleEndian.AppendUint64(payload, math.Float64bits(frequency))
+		}
+	}
+	payload = appendGraph(payload, d.ForwardTrie.root())
+	payload = appendGraph(payload, d.ReverseTrie.root())
+
+	header := make([]byte, 0, len(snapshotMagic)+10)
+	header = append(header, snapshotMagic...)
+	header = binary.LittleEndian.AppendUint16(header, SnapshotVersion)
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(payload)))
+	return err
+}
+
+// ReadSnapshot reads back a dictionary written by WriteSnapshot with the
+// same key
+func ReadSnapshot(r io.Reader, key string) (*WordDictionary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLength := len(snapshotMagic) + 10
+	if len(data) < headerLength+4 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("%w: not a snapshot", ErrSnapshotCorrupt)
+	}
+	if version := binary.LittleEndian.Uint16(data[len(snapshotMagic):]); version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrSnapshotStale, version, SnapshotVersion)
+	}
+	length := binary.LittleEndian.Uint64(data[len(snapshotMagic)+2:])
+	if length != uint64(len(data)-headerLength-4) {
+		return nil, fmt.Errorf("%w: truncated", ErrSnapshotCorrupt)
+	}
+	payload := data[headerLength : len(data)-4]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+
+	buf := bytes.NewReader(payload)
+	saved, err := readString(buf)
+	if err != nil {
+		return nil, err
+	}
+	if saved != key {
+		return nil, ErrSnapshotStale
+	}
+	flags, err := buf.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+	}
+
+	count, err := readCount(buf)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]string, count)
+	var frequencies map[string]float64
+	if flags&snapshotWeighted != 0 {
+		frequencies = make(map[string]float64, count)
+	}
+	for i := range words {
+		if words[i], err = readString(buf); err != nil {
+			return nil, err
+		}
+		if frequencies != nil {
+			var bits [8]byte
+			if _, err := io.ReadFull(buf, bits[:]); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+			}
+			frequencies[words[i]] = math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+		}
+	}
+	d := &WordDictionary{Alphabet: AlphabetOf(words)}
+
+	forward, err := readGraph(buf)
+	if err != nil {
+		return nil, err
+	}
+	reverse, err := readGraph(buf)
+	if err != nil {
+		return nil, err
+	}
+	if buf.Len() != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrSnapshotCorrupt)
+	}
+
+	if flags&snapshotCompact != 0 {
+		d.ForwardTrie, d.ReverseTrie = forward.dawg(), reverse.dawg()
+		d.setFrequencies(words, frequencies)
+		return d, nil
+	}
+	d.ForwardTrie, d.ReverseTrie = forward.trie(), reverse.trie()
+	d.WordList = words
+	d.WordSet = make(map[string]bool, len(words))
+	for _, word := range words {
+		d.WordSet[word] = true
+	}
+	d.setFrequencies(words, frequencies)
+	return d, nil
+}
+
+// appendString appends a string prefixed with its length
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// appendGraph appends the nodes of a graph, numbering each distinct node
+// once so nodes shared in a DAWG stay shared
+func appendGraph(buf []byte, root graphNode) []byte {
+	index := make(map[graphNode]int)
+	var nodes []byte
+	var number func(node graphNode) int
+	number = func(node graphNode) int {
+		if i, seen := index[node]; seen {
+			return i
+		}
+		var letters []rune
+		var children []int
+		node.eachChild(func(ch rune, child graphNode) {
+			letters = append(letters, ch)
+			children = append(children, number(child))
+		})
+
+		if node.isWord() {
+			nodes = append(nodes, 1)
+		} else {
+			nodes = append(nodes, 0)
+		}
+		nodes = binary.AppendUvarint(nodes, uint64(len(children)))
+		for i, child := range children {
+			nodes = binary.AppendUvarint(nodes, uint64(letters[i]))
+			nodes = binary.AppendUvarint(nodes, uint64(child))
+		}
+		index[node] = len(index)
+		return index[node]
+	}
+	number(root)
+
+	buf = binary.AppendUvarint(buf, uint64(len(index)))
+	return append(buf, nodes...)
+}
+
+// readCount reads a length, which can't be longer than what is left to read
+func readCount(buf *bytes.Reader) (int, error) {
+	n, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+	}
+	if n > uint64(buf.Len()) {
+		return 0, fmt.Errorf("%w: length %d out of range", ErrSnapshotCorrupt, n)
+	}
+	return int(n), nil
+}
+
+// readString reads a string written by appendString
+func readString(buf *bytes.Reader) (string, error) {
+	n, err := readCount(buf)
+	if err != nil {
+		return "", err
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(buf, s); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+	}
+	return string(s), nil
+}
+
+// snapshotGraph is a graph as read from a snapshot, before it is turned into
+// a trie or a DAWG
+type snapshotGraph struct {
+	isWord  []bool
+	letters [][]rune
+	targets [][]int
+}
+
+// readGraph reads a graph written by appendGraph
+func readGraph(buf *bytes.Reader) (*snapshotGraph, error) {
+	count, err := readCount(buf)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("%w: graph without a root", ErrSnapshotCorrupt)
+	}
+
+	g := &snapshotGraph{isWord: make([]bool, count), letters: make([][]rune, count), targets: make([][]int, count)}
+	for i := 0; i < count; i++ {
+		flag, err := buf.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+		}
+		g.isWord[i] = flag == 1
+
+		edges, err := readCount(buf)
+		if err != nil {
+			return nil, err
+		}
+		g.letters[i] = make([]rune, edges)
+		g.targets[i] = make([]int, edges)
+		for e := 0; e < edges; e++ {
+			letter, err := binary.ReadUvarint(buf)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+			}
+			target, err := binary.ReadUvarint(buf)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+			}
+			// Children come first, which also rules out cycles
+			if target >= uint64(i) {
+				return nil, fmt.Errorf("%w: node %d points forward", ErrSnapshotCorrupt, i)
+			}
+			g.letters[i][e], g.targets[i][e] = rune(letter), int(target)
+		}
+	}
+	return g, nil
+}
+
+// trie builds the trie of the graph
+func (g *snapshotGraph) trie() *Trie {
+	nodes := make([]*TrieNode, len(g.isWord))
+	for i := range nodes {
+		nodes[i] = &TrieNode{Children: make(map[rune]*TrieNode, len(g.letters[i])), IsWord: g.isWord[i]}
+		for e, letter := range g.letters[i] {
+			nodes[i].addChild(letter, nodes[g.targets[i][e]])
+		}
+	}
+	return &Trie{Root: nodes[len(nodes)-1]}
+}
+
+// dawg builds the DAWG of the graph
+func (g *snapshotGraph) dawg() *DAWG {
+	nodes := make([]*DAWGNode, len(g.isWord))
+	for i := range nodes {
+		nodes[i] = &DAWGNode{Edges: make([]DAWGEdge, len(g.letters[i])), IsWord: g.isWord[i]}
+		for e, letter := range g.letters[i] {
+			nodes[i].Edges[e] = DAWGEdge{Letter: letter, Node: nodes[g.targets[i][e]]}
+		}
+		nodes[i].countWords()
+	}
+	return &DAWG{Root: nodes[len(nodes)-1], nodes: len(nodes)}
+}
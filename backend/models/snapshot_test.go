@@ -0,0 +1,89 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, opts := range []DictionaryOptions{{}, {Compact: true}} {
+		original := NewWordDictionaryWithOptions(append([]string{"café"}, sampleWords...), opts)
+		var buf bytes.Buffer
+		if err := WriteSnapshot(&buf, original, "key"); err != nil {
+			t.Fatalf("Failed to write snapshot: %v", err)
+		}
+
+		restored, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), "key")
+		if err != nil {
+			t.Fatalf("Failed to read snapshot: %v", err)
+		}
+		if !equalStringSlices(restored.GetWordList(), original.GetWordList()) || !equalStringSlices(restored.Alphabet, original.Alphabet) {
+			t.Errorf("Expected %v, got %v", original.GetWordList(), restored.GetWordList())
+		}
+		if (restored.WordSet == nil) != opts.Compact {
+			t.Errorf("Expected the word set only without Compact, got %v", restored.WordSet)
+		}
+		for _, word := range original.GetWordList() {
+			if !restored.ContainsWord(word) {
+				t.Errorf("Expected ContainsWord(%q) after the round trip", word)
+			}
+		}
+		if got := restored.FindWordsWithSuffix("fé"); !equalStringSlices(got, []string{"café"}) {
+			t.Errorf("FindWordsWithSuffix(\"fé\") = %v", got)
+		}
+		if opts.Compact && restored.ForwardTrie.(*DAWG).NodeCount() != original.ForwardTrie.(*DAWG).NodeCount() {
+			t.Error("Expected the DAWG nodes to stay shared")
+		}
+	}
+}
+
+func TestSnapshotKeepsFrequencies(t *testing.T) {
+	for _, opts := range []DictionaryOptions{{}, {Compact: true}} {
+		original := NewWeightedWordDictionary(sampleWords, map[string]float64{"band": 0.8, "banana": 0.4}, opts)
+		var buf bytes.Buffer
+		if err := WriteSnapshot(&buf, original, "key"); err != nil {
+			t.Fatalf("Failed to write snapshot: %v", err)
+		}
+		restored, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), "key")
+		if err != nil {
+			t.Fatalf("Failed to read snapshot: %v", err)
+		}
+		for _, word := range sampleWords {
+			want, _ := original.WordFrequency(word)
+			if got, ok := restored.WordFrequency(word); got != want || !ok {
+				t.Errorf("WordFrequency(%q) = %v, %v after the round trip, want %v", word, got, ok, want)
+			}
+		}
+		if got := restored.RankedWordsWithPrefix("ban", 1); !equalStringSlices(got, []string{"band"}) {
+			t.Errorf("RankedWordsWithPrefix(\"ban\", 1) = %v after the round trip", got)
+		}
+	}
+}
+
+func TestSnapshotRejectsStaleAndCorruptData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, newTestDictionary(), "v1"); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	data := buf.Bytes()
+
+	if _, err := ReadSnapshot(bytes.NewReader(data), "v2"); !errors.Is(err, ErrSnapshotStale) {
+		t.Errorf("Expected a stale snapshot for another key, got %v", err)
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	if _, err := ReadSnapshot(bytes.NewReader(corrupt), "v1"); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, err := ReadSnapshot(bytes.NewReader(data[:len(data)-1]), "v1"); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("Expected a truncated snapshot to be rejected, got %v", err)
+	}
+
+	future := append([]byte(nil), data...)
+	future[len(snapshotMagic)]++
+	if _, err := ReadSnapshot(bytes.NewReader(future), "v1"); !errors.Is(err, ErrSnapshotStale) {
+		t.Errorf("Expected another version to be stale, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"iter"
+	"slices"
+	"sort"
+)
+
+// SubstringIndex is a generalized suffix automaton over a word list. Every
+// substring of every word is a path from the root, so checking a fragment and
+// listing the letters that extend it takes time proportional to the fragment,
+// independent of the number of words. Once built, the transitions of all
+// states are kept in one flat slice, as lookups don't need the suffix links.
+type SubstringIndex struct {
+	offsets []int32   // The transitions of state i are edges[offsets[i]:offsets[i+1]]
+	edges   []samEdge // Transitions of every state, sorted by letter within a state
+}
+
+// samBuilder builds the automaton a letter at a time
+type samBuilder struct {
+	states []samState
+}
+
+// samState is a state of the automaton while it is built, standing for a
+// set of substrings that end at the same positions
+type samState struct {
+	length int32     // Length of the longest substring in the state
+	link   int32     // Suffix link, -1 for the root
+	edges  []samEdge // Transitions, sorted by letter
+}
+
+// samEdge is a transition on a single letter
+type samEdge struct {
+	letter rune
+	to     int32
+}
+
+// NewSubstringIndex builds the automaton for the given words
+func NewSubstringIndex(words []string) *SubstringIndex {
+	return newSubstringIndex(slices.Values(words))
+}
+
+// newSubstringIndex builds the automaton for words as they come, so they
+// needn't be held at once
+func newSubstringIndex(words iter.Seq[string]) *SubstringIndex {
+	b := &samBuilder{states: []samState{{link: -1}}}
+	for word := range words {
+		last := int32(0)
+		for _, ch := range word {
+			last = b.extend(last, ch)
+		}
+	}
+	return b.freeze()
+}
+
+// freeze packs the transitions of the states into a SubstringIndex
+func (b *samBuilder) freeze() *SubstringIndex {
+	total := 0
+	for _, state := range b.states {
+		total += len(state.edges)
+	}
+	idx := &SubstringIndex{
+		offsets: make([]int32, 0, len(b.states)+1),
+		edges:   make([]samEdge, 0, total),
+	}
+	for _, state := range b.states {
+		idx.offsets = append(idx.offsets, int32(len(idx.edges)))
+		idx.edges = append(idx.edges, state.edges...)
+	}
+	idx.offsets = append(idx.offsets, int32(len(idx.edges)))
+	return idx
+}
+
+// Contains reports whether fragment occurs inside any word
+func (idx *SubstringIndex) Contains(fragment string) bool {
+	_, ok := idx.walk(fragment)
+	return ok
+}
+
+// GetNextLetters returns the letters that can follow fragment inside a word
+func (idx *SubstringIndex) GetNextLetters(fragment string) []string {
+	state, ok := idx.walk(fragment)
+	if !ok {
+		return []string{}
+	}
+	edges := idx.transitions(state)
+	letters := make([]string, len(edges))
+	for i, e := range edges {
+		letters[i] = string(e.letter)
+	}
+	return letters
+}
+
+// walk follows fragment from the root and returns the state it ends in
+func (idx *SubstringIndex) walk(fragment string) (int32, bool) {
+	state := int32(0)
+	for _, ch := range fragment {
+		next, ok := idx.next(state, ch)
+		if !ok {
+			return 0, false
+		}
+		state = next
+	}
+	return state, true
+}
+
+// transitions returns the transitions from state
+func (idx *SubstringIndex) transitions(state int32) []samEdge {
+	return idx.edges[idx.offsets[state]:idx.offsets[state+1]]
+}
+
+// next returns the transition from state on letter
+func (idx *SubstringIndex) next(state int32, letter rune) (int32, bool) {
+	return findEdge(idx.transitions(state), letter)
+}
+
+// findEdge returns where the transition on letter leads among edges
+func findEdge(edges []samEdge, letter rune) (int32, bool) {
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].letter >= letter })
+	if i < len(edges) && edges[i].letter == letter {
+		return edges[i].to, true
+	}
+	return 0, false
+}
+
+// next returns the transition from state on letter
+func (b *samBuilder) next(state int32, letter rune) (int32, bool) {
+	return findEdge(b.states[state].edges, letter)
+}
+
+// setNext adds or replaces the transition from state on letter
+func (b *samBuilder) setNext(state int32, letter rune, to int32) {
+	edges := b.states[state].edges
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].letter >= letter })
+	if i < len(edges) && edges[i].letter == letter {
+		edges[i].to = to
+		return
+	}
+	edges = append(edges, samEdge{})
+	copy(edges[i+1:], edges[i:])
+	edges[i] = samEdge{letter: letter, to: to}
+	b.states[state].edges = edges
+}
+
+// addState appends a state and returns its number
+func (b *samBuilder) addState(length, link int32, edges []samEdge) int32 {
+	b.states = append(b.states, samState{length: length, link: link, edges: edges})
+	return int32(len(b.states) - 1)
+}
+
+// clone splits q so that its substrings up to the length of p+1 get their
+// own state, redirecting p and its suffix links to the clone
+func (b *samBuilder) clone(p, q int32, letter rune) int32 {
+	edges := make([]samEdge, len(b.states[q].edges))
+	copy(edges, b.states[q].edges)
+	c := b.addState(b.states[p].length+1, b.states[q].link, edges)
+	for p != -1 {
+		if to, ok := b.next(p, letter); !ok || to != q {
+			break
+		}
+		b.setNext(p, letter, c)
+		p = b.states[p].link
+	}
+	b.states[q].link = c
+	return c
+}
+
+// extend appends letter to the word ending in state last, the standard
+// online construction adapted so that words sharing substrings share states
+func (b *samBuilder) extend(last int32, letter rune) int32 {
+	// The extended substring is already known from an earlier word
+	if q, ok := b.next(last, letter); ok {
+		if b.states[last].length+1 == b.states[q].length {
+			return q
+		}
+		return b.clone(last, q, letter)
+	}
+
+	cur := b.addState(b.states[last].length+1, 0, nil)
+	p := last
+	for p != -1 {
+		if _, ok := b.next(p, letter); ok {
+			break
+		}
+		b.setNext(p, letter, cur)
+		p = b.states[p].link
+	}
+	if p == -1 {
+		return cur
+	}
+
+	q, _ := b.next(p, letter)
+	if b.states[p].length+1 == b.states[q].length {
+		b.states[cur].link = q
+	} else {
+		b.states[cur].link = b.clone(p, q, letter)
+	}
+	return cur
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveNextLetters finds the letters following fragment by scanning every word
+func naiveNextLetters(words []string, fragment string) []string {
+	seen := make(map[string]bool)
+	for _, word := range words {
+		for i := 0; i+len(fragment) < len(word); i++ {
+			if strings.HasPrefix(word[i:], fragment) {
+				seen[string(word[i+len(fragment)])] = true
+			}
+		}
+	}
+	letters := make([]string, 0, len(seen))
+	for letter := range seen {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+	return letters
+}
+
+func TestSubstringIndex_Contains(t *testing.T) {
+	idx := NewSubstringIndex(sampleWords)
+
+	for _, word := range sampleWords {
+		for i := 0; i < len(word); i++ {
+			for j := i + 1; j <= len(word); j++ {
+				if !idx.Contains(word[i:j]) {
+					t.Errorf("Expected index to contain %q (from %q)", word[i:j], word)
+				}
+			}
+		}
+	}
+
+	for _, fragment := range []string{"z", "nab", "appa", "bandanas", "cb"} {
+		if idx.Contains(fragment) {
+			t.Errorf("Index should NOT contain %q", fragment)
+		}
+	}
+}
+
+func TestSubstringIndex_GetNextLetters(t *testing.T) {
+	idx := NewSubstringIndex(sampleWords)
+
+	// Every substring of the sample words, plus a few that are absent
+	fragments := []string{"", "z", "xyz"}
+	for _, word := range sampleWords {
+		for i := 0; i < len(word); i++ {
+			for j := i + 1; j <= len(word); j++ {
+				fragments = append(fragments, word[i:j])
+			}
+		}
+	}
+
+	for _, fragment := range fragments {
+		got := idx.GetNextLetters(fragment)
+		want := naiveNextLetters(sampleWords, fragment)
+		if !equalStringSlices(got, want) {
+			t.Errorf("GetNextLetters(%q) = %v; want %v", fragment, got, want)
+		}
+	}
+}
+
+func TestSubstringIndex_Unicode(t *testing.T) {
+	idx := NewSubstringIndex([]string{"señor", "año"})
+
+	if !idx.Contains("ño") {
+		t.Error("Expected index to contain \"ño\"")
+	}
+	got := idx.GetNextLetters("a")
+	if !equalStringSlices(got, []string{"ñ"}) {
+		t.Errorf("GetNextLetters(\"a\") = %v; want [ñ]", got)
+	}
+}
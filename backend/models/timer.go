@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxRoundDuration bounds how long a timed round may last
+const MaxRoundDuration = time.Hour
+
+// TimerState tracks a timed round. The clock is kept on the server: the
+// round starts when the session is created and moves are refused once it
+// runs out, whatever the client shows.
+type TimerState struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Found     []string      `json:"found"` // Valid words made during the round, in order
+}
+
+// StartTimedRound starts the clock on a fresh state
+func StartTimedRound(state WordBuilderState, duration time.Duration, now time.Time) (WordBuilderState, error) {
+	if duration <= 0 || duration > MaxRoundDuration {
+		return state, fmt.Errorf("duration must be between 1s and %s", MaxRoundDuration)
+	}
+	state.Timer = &TimerState{StartedAt: now, Duration: duration, Found: []string{}}
+	return state, nil
+}
+
+// Remaining returns the time left in the round at now
+func (t *TimerState) Remaining(now time.Time) time.Duration {
+	remaining := t.StartedAt.Add(t.Duration).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Expired reports whether the round is over at now
+func (t *TimerState) Expired(now time.Time) bool {
+	return t.Remaining(now) == 0
+}
+
+// RecordFoundWord adds the answer to the words found during the round if it
+// is a valid word that wasn't found before
+func RecordFoundWord(state WordBuilderState) WordBuilderState {
+	if state.Timer == nil || !state.IsValidWord {
+		return state
+	}
+	for _, word := range state.Timer.Found {
+		if word == state.Answer {
+			return state
+		}
+	}
+
+	timer := *state.Timer
+	timer.Found = append(append(make([]string, 0, len(timer.Found)+1), timer.Found...), state.Answer)
+	state.Timer = &timer
+	return state
+}
+
+// GetTimerView formats the timer for the API as of now
+func GetTimerView(timer *TimerState, now time.Time) map[string]interface{} {
+	if timer == nil {
+		return nil
+	}
+	found := timer.Found
+	if found == nil {
+		found = []string{}
+	}
+	return map[string]interface{}{
+		"started_at":        timer.StartedAt,
+		"ends_at":           timer.StartedAt.Add(timer.Duration),
+		"duration_seconds":  timer.Duration.Seconds(),
+		"remaining_seconds": math.Ceil(timer.Remaining(now).Seconds()),
+		"expired":           timer.Expired(now),
+		"found":             found,
+	}
+}
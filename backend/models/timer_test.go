@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartTimedRound(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, duration := range []time.Duration{0, -time.Second, MaxRoundDuration + time.Second} {
+		if _, err := StartTimedRound(WordBuilderState{}, duration, now); err == nil {
+			t.Errorf("Expected an error for a %s round", duration)
+		}
+	}
+
+	state, err := StartTimedRound(WordBuilderState{}, time.Minute, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := state.Timer.Remaining(now.Add(20 * time.Second)); got != 40*time.Second {
+		t.Errorf("Expected 40s remaining, got %s", got)
+	}
+	if state.Timer.Expired(now.Add(59 * time.Second)) {
+		t.Error("Round should not have expired yet")
+	}
+	if !state.Timer.Expired(now.Add(time.Minute)) || state.Timer.Remaining(now.Add(time.Hour)) != 0 {
+		t.Error("Round should have expired")
+	}
+}
+
+func TestRecordFoundWord(t *testing.T) {
+	state, _ := StartTimedRound(WordBuilderState{}, time.Minute, time.Now())
+	before := state
+
+	state.Answer, state.IsValidWord = "cat", true
+	state = RecordFoundWord(state)
+	state = RecordFoundWord(state)
+	state.Answer, state.IsValidWord = "cats", false
+	state = RecordFoundWord(state)
+
+	if !equalStringSlices(state.Timer.Found, []string{"cat"}) {
+		t.Errorf("Expected only 'cat' found, got %v", state.Timer.Found)
+	}
+	if len(before.Timer.Found) != 0 {
+		t.Error("Recording a word must not change earlier states")
+	}
+}
@@ -1,9 +1,17 @@
 package models
 
+import (
+	"container/heap"
+	"slices"
+)
+
 // TrieNode represents a node in the Trie
 type TrieNode struct {
 	Children map[rune]*TrieNode
 	IsWord   bool
+	Weight   float64 // Weight of the word ending here, such as how common it is
+	letters  []rune  // Letters of the children, sorted, so walks visit them in order
+	best     float64 // Highest weight of the words below, at least
 }
 
 // Trie represents a dictionary structure for efficient prefix searches
@@ -18,14 +26,30 @@ func NewTrie() *Trie {
 
 // Insert adds a word to the Trie
 func (t *Trie) Insert(word string) {
+	t.InsertWeighted(word, 0)
+}
+
+// InsertWeighted adds a word with a weight, or sets the weight of a word
+// already there. TopKeysWithPrefix lists the heaviest words first.
+func (t *Trie) InsertWeighted(word string, weight float64) {
 	node := t.Root
+	node.best = max(node.best, weight)
 	for _, ch := range word {
 		if _, exists := node.Children[ch]; !exists {
-			node.Children[ch] = &TrieNode{Children: make(map[rune]*TrieNode)}
+			node.addChild(ch, &TrieNode{Children: make(map[rune]*TrieNode)})
 		}
 		node = node.Children[ch]
+		node.best = max(node.best, weight)
 	}
 	node.IsWord = true
+	node.Weight = weight
+}
+
+// addChild links a new child, keeping the letters sorted
+func (n *TrieNode) addChild(ch rune, child *TrieNode) {
+	n.Children[ch] = child
+	i, _ := slices.BinarySearch(n.letters, ch)
+	n.letters = slices.Insert(n.letters, i, ch)
 }
 
 // Contains checks if a word exists in the Trie
@@ -40,28 +64,30 @@ func (t *Trie) Contains(word string) bool {
 	return node.IsWord
 }
 
-// KeysWithPrefix returns all words that start with the given prefix
-func (t *Trie) KeysWithPrefix(prefix string) []string {
-	var results []string
+// Weight returns the weight of a word, if the Trie holds it
+func (t *Trie) Weight(word string) (float64, bool) {
 	node := t.Root
-	for _, ch := range prefix {
-		if _, exists := node.Children[ch]; !exists {
-			return results
+	for _, ch := range word {
+		child, exists := node.Children[ch]
+		if !exists {
+			return 0, false
 		}
-		node = node.Children[ch]
+		node = child
 	}
-	t.collectKeys(node, prefix, &results)
-	return results
+	return node.Weight, node.IsWord
 }
 
-// collectKeys recursively collects all words from the current node
-func (t *Trie) collectKeys(node *TrieNode, prefix string, results *[]string) {
-	if node.IsWord {
-		*results = append(*results, prefix)
-	}
-	for ch, child := range node.Children {
-		t.collectKeys(child, prefix+string(ch), results)
-	}
+// KeysWithPrefix returns all words that start with the given prefix, sorted
+func (t *Trie) KeysWithPrefix(prefix string) []string {
+	return keysWithPrefix(t.Root, prefix, "", 0)
+}
+
+// KeysWithPrefixAfter returns the words that start with the given prefix and
+// sort after cursor, up to limit words. Passing the last word of a page as
+// the cursor gets the next page; an empty cursor starts from the first word
+// and a limit of 0 returns every word.
+func (t *Trie) KeysWithPrefixAfter(prefix, cursor string, limit int) []string {
+	return keysWithPrefix(t.Root, prefix, cursor, limit)
 }
 
 // GetNextLetters returns the possible next letters after a given prefix
@@ -74,9 +100,74 @@ func (t *Trie) GetNextLetters(prefix string) []string {
 			return []string{}
 		}
 	}
-	letters := make([]string, 0, len(node.Children))
-	for ch := range node.Children {
+	letters := make([]string, 0, len(node.letters))
+	for _, ch := range node.letters {
 		letters = append(letters, string(ch))
 	}
 	return letters
 }
+
+// TopKeysWithPrefix returns up to limit words that start with the given
+// prefix, the heaviest first and in alphabetical order on ties. Branches are
+// visited best first, so only the nodes leading to the words returned and
+// their siblings are looked at.
+func (t *Trie) TopKeysWithPrefix(prefix string, limit int) []string {
+	var results []string
+	node := t.Root
+	for _, ch := range prefix {
+		child, exists := node.Children[ch]
+		if !exists {
+			return results
+		}
+		node = child
+	}
+
+	queue := &weightedQueue{{node: node, word: prefix, weight: node.best}}
+	for queue.Len() > 0 && (limit <= 0 || len(results) < limit) {
+		item := heap.Pop(queue).(weightedItem)
+		if item.node == nil {
+			results = append(results, item.word)
+			continue
+		}
+		if item.node.IsWord {
+			heap.Push(queue, weightedItem{word: item.word, weight: item.node.Weight})
+		}
+		for _, ch := range item.node.letters {
+			child := item.node.Children[ch]
+			heap.Push(queue, weightedItem{node: child, word: item.word + string(ch), weight: child.best})
+		}
+	}
+	return results
+}
+
+// weightedItem is a branch of the trie to visit, weighted by its best word,
+// or a word found, once node is nil
+type weightedItem struct {
+	node   *TrieNode
+	word   string
+	weight float64
+}
+
+// weightedQueue pops the heaviest items first, then the first in
+// alphabetical order. A branch comes before the words below it, as its word
+// is their prefix, so they are all queued by the time they can be popped.
+type weightedQueue []weightedItem
+
+func (q weightedQueue) Len() int { return len(q) }
+func (q weightedQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight > q[j].weight
+	}
+	if q[i].word != q[j].word {
+		return q[i].word < q[j].word
+	}
+	return q[i].node != nil
+}
+func (q weightedQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *weightedQueue) Push(x any)   { *q = append(*q, x.(weightedItem)) }
+func (q *weightedQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -1,9 +1,6 @@
 package models
 
-import (
-	"sort"
-	"testing"
-)
+import "testing"
 
 func TestTrie_InsertAndContains(t *testing.T) {
 	trie := NewTrie()
@@ -51,14 +48,87 @@ func TestTrie_KeysWithPrefix(t *testing.T) {
 
 	for _, tt := range tests {
 		got := trie.KeysWithPrefix(tt.prefix)
-		sort.Strings(got)
-		sort.Strings(tt.expected)
 		if !equalStringSlices(got, tt.expected) {
 			t.Errorf("KeysWithPrefix(%q) = %v; want %v", tt.prefix, got, tt.expected)
 		}
 	}
 }
 
+func TestTrie_KeysWithPrefixAfter(t *testing.T) {
+	trie := NewTrie()
+	for _, word := range []string{"cab", "bandana", "app", "band", "apple", "banana"} {
+		trie.Insert(word)
+	}
+
+	tests := []struct {
+		prefix   string
+		cursor   string
+		limit    int
+		expected []string
+	}{
+		{"", "", 2, []string{"app", "apple"}},
+		{"", "apple", 2, []string{"banana", "band"}},
+		{"", "band", 2, []string{"bandana", "cab"}},
+		{"", "cab", 2, []string{}},
+		{"ban", "", 0, []string{"banana", "band", "bandana"}},
+		{"ban", "banana", 0, []string{"band", "bandana"}},
+		{"band", "band", 5, []string{"bandana"}},
+		{"ban", "bamboo", 1, []string{"banana"}},
+		{"ban", "bank", 0, []string{}},
+		{"ban", "apple", 1, []string{"banana"}},
+		{"ban", "cab", 0, []string{}},
+		{"z", "", 1, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := trie.KeysWithPrefixAfter(tt.prefix, tt.cursor, tt.limit)
+		if !equalStringSlices(got, tt.expected) {
+			t.Errorf("KeysWithPrefixAfter(%q, %q, %d) = %v; want %v", tt.prefix, tt.cursor, tt.limit, got, tt.expected)
+		}
+	}
+
+	// Paging with the last word as the cursor walks every word once
+	var paged []string
+	for page := trie.KeysWithPrefixAfter("", "", 4); len(page) > 0; page = trie.KeysWithPrefixAfter("", page[len(page)-1], 4) {
+		paged = append(paged, page...)
+	}
+	if want := trie.KeysWithPrefix(""); !equalStringSlices(paged, want) {
+		t.Errorf("Paging KeysWithPrefixAfter = %v; want %v", paged, want)
+	}
+}
+
+func TestTrie_TopKeysWithPrefix(t *testing.T) {
+	trie := NewTrie()
+	for word, weight := range map[string]float64{"app": 0.5, "apple": 0.9, "banana": 0.7, "band": 0.7, "bandana": 0.2, "cab": 0} {
+		trie.InsertWeighted(word, weight)
+	}
+
+	tests := []struct {
+		prefix   string
+		limit    int
+		expected []string
+	}{
+		{"", 3, []string{"apple", "banana", "band"}},
+		{"", 0, []string{"apple", "banana", "band", "app", "bandana", "cab"}},
+		{"ban", 2, []string{"banana", "band"}},
+		{"band", 1, []string{"band"}},
+		{"z", 1, []string{}},
+	}
+	for _, tt := range tests {
+		got := trie.TopKeysWithPrefix(tt.prefix, tt.limit)
+		if !equalStringSlices(got, tt.expected) {
+			t.Errorf("TopKeysWithPrefix(%q, %d) = %v; want %v", tt.prefix, tt.limit, got, tt.expected)
+		}
+	}
+
+	if weight, ok := trie.Weight("apple"); weight != 0.9 || !ok {
+		t.Errorf("Weight(\"apple\") = %v, %v", weight, ok)
+	}
+	if _, ok := trie.Weight("appl"); ok {
+		t.Error("Expected no weight for a prefix")
+	}
+}
+
 func TestTrie_GetNextLetters(t *testing.T) {
 	trie := NewTrie()
 	words := []string{"apple", "app", "banana", "band", "bandana", "cab"}
@@ -80,10 +150,75 @@ func TestTrie_GetNextLetters(t *testing.T) {
 
 	for _, tt := range tests {
 		got := trie.GetNextLetters(tt.prefix)
-		sort.Strings(got)
-		sort.Strings(tt.expected)
 		if !equalStringSlices(got, tt.expected) {
 			t.Errorf("GetNextLetters(%q) = %v; want %v", tt.prefix, got, tt.expected)
 		}
 	}
 }
+
+func TestTrie_MatchPattern(t *testing.T) {
+	trie := NewTrie()
+	words := []string{"apple", "app", "banana", "band", "bandana", "cab", "cat", "cot", "coat"}
+	for _, word := range words {
+		trie.Insert(word)
+	}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"c?t", []string{"cat", "cot"}},
+		{"C?T", []string{"cat", "cot"}},
+		{"c*t", []string{"cat", "coat", "cot"}},
+		{"*a", []string{"banana", "bandana"}},
+		{"b??d*", []string{"band", "bandana"}},
+		{"*an*", []string{"banana", "band", "bandana"}},
+		{"**", []string{"app", "apple", "banana", "band", "bandana", "cab", "cat", "coat", "cot"}},
+		{"c[ao]t", []string{"cat", "cot"}},
+		{"c[^a]t", []string{"cot"}},
+		{"[a-b]*e", []string{"apple"}},
+		{"app", []string{"app"}},
+		{"z*", []string{}},
+	}
+
+	for _, tt := range tests {
+		got, err := trie.MatchPattern(tt.pattern)
+		if err != nil || !equalStringSlices(got, tt.expected) {
+			t.Errorf("MatchPattern(%q) = %v, %v; want %v", tt.pattern, got, err, tt.expected)
+		}
+	}
+
+	for _, pattern := range []string{"", "c[at", "c]t", "c[]t", "c[z-a]t"} {
+		if _, err := trie.MatchPattern(pattern); err == nil {
+			t.Errorf("MatchPattern(%q) should fail", pattern)
+		}
+	}
+}
+
+func TestTrie_MatchPatternAfter(t *testing.T) {
+	trie := NewTrie()
+	for _, word := range []string{"apple", "app", "banana", "band", "bandana", "cab", "cat", "cot", "coat"} {
+		trie.Insert(word)
+	}
+
+	tests := []struct {
+		pattern  string
+		cursor   string
+		limit    int
+		expected []string
+	}{
+		{"*a*", "", 3, []string{"app", "apple", "banana"}},
+		{"*a*", "banana", 2, []string{"band", "bandana"}},
+		{"*a*", "bandana", 0, []string{"cab", "cat", "coat"}},
+		{"*a*", "ba", 1, []string{"banana"}},
+		{"c*t", "cb", 0, []string{"coat", "cot"}},
+		{"c*t", "cot", 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		got, err := trie.MatchPatternAfter(tt.pattern, tt.cursor, tt.limit)
+		if err != nil || !equalStringSlices(got, tt.expected) {
+			t.Errorf("MatchPatternAfter(%q, %q, %d) = %v, %v; want %v", tt.pattern, tt.cursor, tt.limit, got, err, tt.expected)
+		}
+	}
+}
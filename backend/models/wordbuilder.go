@@ -2,28 +2,55 @@ package models
 
 import (
 	"fmt"
-	"runtime"
+	"slices"
 	"sort"
 	"strings"
-	"sync"
+	"time"
+	"unicode/utf8"
 
 	utils "wordbuilder/utils"
 )
 
 // WordBuilderState holds the state for the word builder game, without any dependencies or methods.
 type WordBuilderState struct {
-	Answer           string
-	PrefixSet        map[string]bool
-	SuffixSet        map[string]bool
-	Step             int
-	IsValidWord      bool
-	ValidCompletions []string
-	Suggestion       string
+	Answer           string            `json:"answer"`
+	PrefixSet        map[string]bool   `json:"prefix_set"`
+	SuffixSet        map[string]bool   `json:"suffix_set"`
+	GapSets          []map[string]bool `json:"gap_sets"` // Letters that can be inserted between two letters, see gapSets
+	Step             int               `json:"step"`
+	IsValidWord      bool              `json:"is_valid_word"`
+	ValidCompletions []string          `json:"valid_completions"`
+	History          []Move            `json:"history"`          // Moves made so far, most recent last
+	Undone           []Move            `json:"undone"`           // Moves undone and available to redo, most recent last
+	Hints            []Hint            `json:"hints"`            // Hints taken so far, in order
+	Ghost            *GhostState       `json:"ghost,omitempty"`  // Set when playing Superghost against the computer
+	Timer            *TimerState       `json:"timer,omitempty"`  // Set when playing against the clock
+	Puzzle           *PuzzleState      `json:"puzzle,omitempty"` // Set when building toward a hidden target word
+	Daily            *DailyState       `json:"daily,omitempty"`  // Set when playing the puzzle of the day
+	Rack             *RackState        `json:"rack,omitempty"`   // Set when building from a rack of letter tiles
+	Score            ScoreState        `json:"score"`            // Points accumulated across the words of the session
+	WordListID       int               `json:"word_list_id"`     // Word list the session plays with, 0 for the built-in default
+	LastActivity     time.Time         `json:"last_activity"`    // Time of the last move, used to expire idle sessions
 }
 
 type TrieI interface {
 	GetNextLetters(prefix string) []string
 	KeysWithPrefix(prefix string) []string
+	// KeysWithPrefixAfter lists up to limit words with the prefix that sort
+	// after cursor, stopping as soon as it has found them
+	KeysWithPrefixAfter(prefix, cursor string, limit int) []string
+}
+
+// SubstringIndexI finds the letters that can follow a fragment anywhere inside a word
+type SubstringIndexI interface {
+	GetNextLetters(fragment string) []string
+}
+
+// RankedWordsI is implemented by dictionaries that can list their commonest
+// words first
+type RankedWordsI interface {
+	RankedWordsWithPrefix(prefix string, limit int) []string
+	RankedWordsWithSuffix(suffix string, limit int) []string
 }
 
 // WordDictionary defines the methods required by the word builder logic.
@@ -34,6 +61,9 @@ type WordDictionaryI interface {
 	GetForwardTrie() TrieI
 	GetReverseTrie() TrieI
 	GetWordList() []string
+	GetAlphabet() []string
+	GetSubstringIndex() SubstringIndexI
+	GetReverseSubstringIndex() SubstringIndexI
 }
 
 // CheckValidWord verifies if the current answer is a valid word
@@ -46,6 +76,9 @@ func AddLetter(state WordBuilderState, dict WordDictionaryI, letter, position st
 	// Work with a copy of state
 	newState := state
 	letter = strings.ToLower(letter)
+	if !IsLetter(dict, letter) {
+		return state, fmt.Sprintf("'%s' is not a letter of this word list.", letter), fmt.Errorf("invalid letter")
+	}
 
 	if position == "prefix" {
 		if !state.PrefixSet[letter] {
@@ -66,6 +99,12 @@ func AddLetter(state WordBuilderState, dict WordDictionaryI, letter, position st
 	newState = UpdateSets(newState, dict)
 	newState.Step++
 
+	index := 0
+	if position == "suffix" {
+		index = utf8.RuneCountInString(newState.Answer) - 1
+	}
+	newState = recordMove(newState, Move{Action: "add", Letter: letter, Position: position, Index: index, Answer: newState.Answer})
+
 	message := fmt.Sprintf("Step %d: Added '%s' as %s -> Answer: %s", newState.Step, letter, position, newState.Answer)
 	if newState.IsValidWord {
 		message += fmt.Sprintf("\n*** '%s' is a valid word! ***", newState.Answer)
@@ -79,19 +118,22 @@ func AddLetter(state WordBuilderState, dict WordDictionaryI, letter, position st
 	return newState, message, nil
 }
 
-// RemoveLetter removes a letter at the specified index
+// RemoveLetter removes the letter at the specified index, counted in letters
+// rather than bytes
 func RemoveLetter(state WordBuilderState, dict WordDictionaryI, index int) (WordBuilderState, string, error) {
-	if index < 0 || index >= len(state.Answer) {
+	runes := []rune(state.Answer)
+	if index < 0 || index >= len(runes) {
 		return state, fmt.Sprintf("Invalid index %d for answer '%s'.", index, state.Answer), fmt.Errorf("invalid index")
 	}
 
 	newState := state
-	letter := string(state.Answer[index])
-	newState.Answer = state.Answer[:index] + state.Answer[index+1:]
+	letter := string(runes[index])
+	newState.Answer = string(runes[:index]) + string(runes[index+1:])
 
 	newState.IsValidWord = CheckValidWord(newState, dict)
 	newState = UpdateSets(newState, dict)
 	newState.Step++
+	newState = recordMove(newState, Move{Action: "remove", Letter: letter, Index: index, Answer: newState.Answer})
 
 	message := fmt.Sprintf("Step %d: Removed '%s' at index %d -> Answer: %s", newState.Step, letter, index, newState.Answer)
 	if newState.IsValidWord {
@@ -101,45 +143,36 @@ func RemoveLetter(state WordBuilderState, dict WordDictionaryI, index int) (Word
 	return newState, message, nil
 }
 
+// maxCompletions bounds the completions listed on each side of the answer
+const maxCompletions = 5
+
 // UpdateSets updates the available prefix and suffix letter sets
 func UpdateSets(state WordBuilderState, dict WordDictionaryI) WordBuilderState {
 	newState := state
 	newState.PrefixSet = make(map[string]bool)
 	newState.SuffixSet = make(map[string]bool)
 	newState.ValidCompletions = []string{}
+	newState.GapSets = nil
 
-	// If no letters yet, provide all letters that can start or end words
+	// If no letters yet, provide all letters that can start or end words,
+	// which are the first letters of the forward and reverse tries
 	if len(newState.Answer) == 0 {
-		for _, letter := range "abcdefghijklmnopqrstuvwxyz" {
-			letterStr := string(letter)
-			if len(dict.FindWordsWithPrefix(letterStr)) > 0 {
-				newState.PrefixSet[letterStr] = true
-			}
-			if len(dict.FindWordsWithSuffix(letterStr)) > 0 {
-				newState.SuffixSet[letterStr] = true
-			}
+		for _, letter := range dict.GetForwardTrie().GetNextLetters("") {
+			newState.PrefixSet[letter] = true
+		}
+		for _, letter := range dict.GetReverseTrie().GetNextLetters("") {
+			newState.SuffixSet[letter] = true
 		}
 		return newState
 	}
 
-	foundValidContinuation := false
-
 	// 1. Suffix letters from ForwardTrie
 	suffixLetters := dict.GetForwardTrie().GetNextLetters(newState.Answer)
 	for _, letter := range suffixLetters {
 		newState.SuffixSet[letter] = true
-		foundValidContinuation = true
 	}
 	if len(suffixLetters) > 0 {
-		words := dict.FindWordsWithPrefix(newState.Answer)
-		for i, word := range words {
-			if i >= 5 {
-				break
-			}
-			if len(word) > len(newState.Answer) {
-				newState.ValidCompletions = append(newState.ValidCompletions, word)
-			}
-		}
+		newState.ValidCompletions = append(newState.ValidCompletions, completions(dict, newState.Answer, false)...)
 	}
 
 	// 2. Prefix letters from ReverseTrie
@@ -148,85 +181,78 @@ func UpdateSets(state WordBuilderState, dict WordDictionaryI) WordBuilderState {
 	prefixLetters := dict.GetReverseTrie().GetNextLetters(reversedAnswer)
 	for _, letter := range prefixLetters {
 		newState.PrefixSet[letter] = true
-		foundValidContinuation = true
 	}
 	if len(prefixLetters) > 0 {
-		revWords := dict.GetReverseTrie().KeysWithPrefix(reversedAnswer)
-		for i, revWord := range revWords {
-			if i >= 5 {
-				break
-			}
-			if len(revWord) > len(reversedAnswer) {
-				newState.ValidCompletions = append(newState.ValidCompletions, utils.ReverseString(revWord))
-			}
-		}
-	}
-
-	// 3. Embedded check with parallelism
-	wordList := dict.GetWordList()
-	numParts := runtime.NumCPU()
-	partSize := (len(wordList) + numParts - 1) / numParts
-	var wg sync.WaitGroup
-	type Result struct {
-		prefixSet map[string]bool
-		suffixSet map[string]bool
+		newState.ValidCompletions = append(newState.ValidCompletions, completions(dict, newState.Answer, true)...)
 	}
-	results := make(chan Result, numParts)
 
-	for i := 0; i < numParts; i++ {
-		start := i * partSize
-		end := start + partSize
-		if end > len(wordList) {
-			end = len(wordList)
+	// 3. Letters that keep the answer embedded inside some longer word
+	if index := dict.GetSubstringIndex(); index != nil {
+		for _, letter := range index.GetNextLetters(newState.Answer) {
+			newState.SuffixSet[letter] = true
 		}
-		if start > end {
-			continue
+	}
+	if index := dict.GetReverseSubstringIndex(); index != nil {
+		for _, letter := range index.GetNextLetters(reversedAnswer) {
+			newState.PrefixSet[letter] = true
 		}
-		wg.Add(1)
-		go func(words []string) {
-			defer wg.Done()
-			// Process words and send result
-			localPrefix := make(map[string]bool)
-			localSuffix := make(map[string]bool)
-			// ... processing logic ...
-			results <- Result{localPrefix, localSuffix}
-		}(wordList[start:end])
 	}
 
-	// Collect results concurrently
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	// 4. Letters that can go between two letters of the answer
+	newState.GapSets = gapSets(newState.Answer, dict)
 
-	for res := range results {
-		for letter := range res.prefixSet {
-			newState.PrefixSet[letter] = true
+	// Commonest completions first when the dictionary knows, then the
+	// shortest, as they are the easiest to reach
+	frequency := frequencyOf(dict)
+	sort.SliceStable(newState.ValidCompletions, func(i, j int) bool {
+		a, b := newState.ValidCompletions[i], newState.ValidCompletions[j]
+		if fa, fb := frequency(a), frequency(b); fa != fb {
+			return fa > fb
 		}
-		for letter := range res.suffixSet {
-			newState.SuffixSet[letter] = true
+		return len(a) < len(b)
+	})
+
+	return newState
+}
+
+// completions lists up to maxCompletions words extending the answer at the
+// end, or at the start when before is set. Dictionaries that rank their
+// words give their commonest.
+func completions(dict WordDictionaryI, answer string, before bool) []string {
+	if ranked, ok := dict.(RankedWordsI); ok {
+		var words []string
+		if before {
+			words = ranked.RankedWordsWithSuffix(answer, maxCompletions+1)
+		} else {
+			words = ranked.RankedWordsWithPrefix(answer, maxCompletions+1)
 		}
+		words = slices.DeleteFunc(words, func(word string) bool { return word == answer })
+		return words[:min(len(words), maxCompletions)]
 	}
 
-	// Generate suggestion (simplified)
-	if len(newState.ValidCompletions) > 0 && !newState.IsValidWord {
-		sort.Slice(newState.ValidCompletions, func(i, j int) bool {
-			return len(newState.ValidCompletions[i]) < len(newState.ValidCompletions[j])
-		})
-		shortest := newState.ValidCompletions[0]
-		if strings.HasPrefix(shortest, newState.Answer) && len(shortest) > len(newState.Answer) {
-			newState.Suggestion = "Try adding '" + string(shortest[len(newState.Answer)]) + "' as suffix"
-		} else if idx := strings.Index(shortest, newState.Answer); idx > 0 {
-			newState.Suggestion = "Try adding '" + string(shortest[idx-1]) + "' as prefix"
+	// The answer sorts before its completions, so it makes the cursor
+	if before {
+		reversed := utils.ReverseString(answer)
+		words := dict.GetReverseTrie().KeysWithPrefixAfter(reversed, reversed, maxCompletions)
+		for i, word := range words {
+			words[i] = utils.ReverseString(word)
 		}
+		return words
 	}
+	return dict.GetForwardTrie().KeysWithPrefixAfter(answer, answer, maxCompletions)
+}
 
-	// When a valid word or we have nowhere to go
-	if newState.IsValidWord || !foundValidContinuation {
-		newState.Suggestion = "This isn't a valid prefix or suffix of any word. Try removing some letters."
+// frequencyOf returns how common the words of a dictionary are, 0 for every
+// word when the dictionary doesn't know
+func frequencyOf(dict WordDictionaryI) func(word string) float64 {
+	frequencies, ok := dict.(WordFrequencyI)
+	return func(word string) float64 {
+		if !ok {
+			return 0
+		}
+		frequency, _ := frequencies.WordFrequency(word)
+		return frequency
 	}
-
-	return newState
 }
 
 // GetCurrentState returns the current state as a map
@@ -240,6 +266,9 @@ func GetCurrentState(state WordBuilderState) map[string]interface{} {
 	for letter := range state.SuffixSet {
 		suffixSet = append(suffixSet, letter)
 	}
+	// Sorted like the gap sets, so responses don't change with map order
+	sort.Strings(prefixSet)
+	sort.Strings(suffixSet)
 
 	// Only return a few completions to avoid overwhelming the UI
 	var displayCompletions []string
@@ -249,13 +278,31 @@ func GetCurrentState(state WordBuilderState) map[string]interface{} {
 		displayCompletions = state.ValidCompletions
 	}
 
+	history := state.History
+	if history == nil {
+		history = []Move{}
+	}
+
 	return map[string]interface{}{
 		"answer":            state.Answer,
 		"prefix_set":        prefixSet,
 		"suffix_set":        suffixSet,
+		"gap_sets":          GetGapSetsView(state.GapSets),
 		"step":              state.Step,
 		"is_valid_word":     state.IsValidWord,
 		"valid_completions": displayCompletions,
-		"suggestion":        state.Suggestion,
+		"hints":             GetHintsView(state.Hints),
+		"next_hint_level":   nextHintLevel(state),
+		"history":           history,
+		"can_undo":          len(state.History) > 0,
+		"can_redo":          len(state.Undone) > 0,
+		"ghost":             GetGhostView(state.Ghost),
+		"timer":             GetTimerView(state.Timer, time.Now()),
+		"puzzle":            GetPuzzleView(state.Puzzle),
+		"daily":             GetDailyView(state.Daily),
+		"rack":              GetRackView(state.Rack, state.Answer),
+		"score":             GetScoreView(state.Score),
+		"word_list_id":      state.WordListID,
+		"last_activity":     state.LastActivity,
 	}
 }
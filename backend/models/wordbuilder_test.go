@@ -13,6 +13,9 @@ type MockWordDictionary struct {
 	wordList    []string
 	forwardTrie *MockTrie
 	reverseTrie *MockTrie
+	// Substring indexes are optional, nil means no embedded letters
+	substringIndex        *MockTrie
+	reverseSubstringIndex *MockTrie
 }
 
 func (m *MockWordDictionary) ContainsWord(word string) bool {
@@ -36,6 +39,23 @@ func (m *MockWordDictionary) GetWordList() []string {
 	return m.wordList
 }
 
+func (m *MockWordDictionary) GetAlphabet() []string {
+	return AlphabetOf(m.wordList)
+}
+
+func (m *MockWordDictionary) GetSubstringIndex() SubstringIndexI {
+	if m.substringIndex == nil {
+		return nil
+	}
+	return m.substringIndex
+}
+func (m *MockWordDictionary) GetReverseSubstringIndex() SubstringIndexI {
+	if m.reverseSubstringIndex == nil {
+		return nil
+	}
+	return m.reverseSubstringIndex
+}
+
 // MockTrie for minimal interface usage in UpdateSets
 type MockTrie struct {
 	nextLetters  map[string][]string
@@ -48,6 +68,15 @@ func (t *MockTrie) GetNextLetters(prefix string) []string {
 func (t *MockTrie) KeysWithPrefix(prefix string) []string {
 	return t.keysWithPref[prefix]
 }
+func (t *MockTrie) KeysWithPrefixAfter(prefix, cursor string, limit int) []string {
+	var keys []string
+	for _, key := range t.keysWithPref[prefix] {
+		if key > cursor && (limit == 0 || len(keys) < limit) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
 
 // --- Tests ---
 
@@ -173,11 +202,11 @@ func TestUpdateSets(t *testing.T) {
 		suffixWords: map[string][]string{"t": {"cat", "at"}},
 		wordList:    []string{"cat", "at"},
 		forwardTrie: &MockTrie{
-			nextLetters:  map[string][]string{"ca": {"t"}},
+			nextLetters:  map[string][]string{"": {"a", "c"}, "ca": {"t"}},
 			keysWithPref: map[string][]string{"ca": {"cat"}},
 		},
 		reverseTrie: &MockTrie{
-			nextLetters:  map[string][]string{"ta": {"c"}},
+			nextLetters:  map[string][]string{"": {"t"}, "ta": {"c"}},
 			keysWithPref: map[string][]string{"ta": {"tac"}},
 		},
 	}
@@ -193,18 +222,77 @@ func TestUpdateSets(t *testing.T) {
 func TestGetCurrentState(t *testing.T) {
 	state := WordBuilderState{
 		Answer:           "cat",
-		PrefixSet:        map[string]bool{"a": true, "b": true},
-		SuffixSet:        map[string]bool{"c": true},
+		PrefixSet:        map[string]bool{"s": true, "a": true, "b": true},
+		SuffixSet:        map[string]bool{"h": true, "c": true},
 		Step:             2,
 		IsValidWord:      true,
 		ValidCompletions: []string{"cat", "cats", "catch"},
-		Suggestion:       "Try adding 's'",
 	}
 	got := GetCurrentState(state)
 	if got["answer"] != "cat" || got["step"] != 2 || got["is_valid_word"] != true {
 		t.Errorf("GetCurrentState() returned wrong values: %+v", got)
 	}
-	if !reflect.DeepEqual(got["prefix_set"], []string{"a", "b"}) && !reflect.DeepEqual(got["prefix_set"], []string{"b", "a"}) {
-		t.Errorf("GetCurrentState() prefix_set mismatch: %+v", got["prefix_set"])
+	if !reflect.DeepEqual(got["prefix_set"], []string{"a", "b", "s"}) || !reflect.DeepEqual(got["suffix_set"], []string{"c", "h"}) {
+		t.Errorf("GetCurrentState() letter sets mismatch: %+v %+v", got["prefix_set"], got["suffix_set"])
+	}
+}
+
+func TestUpdateSetsEmbeddedLetters(t *testing.T) {
+	// "an" only extends to "and"/"ban" inside longer words like "bandana"
+	dict := NewWordDictionary([]string{"bandana"})
+	state := UpdateSets(WordBuilderState{Answer: "an"}, dict)
+
+	for _, letter := range []string{"a", "d"} {
+		if !state.SuffixSet[letter] {
+			t.Errorf("Expected %q in SuffixSet, got %v", letter, state.SuffixSet)
+		}
+	}
+	for _, letter := range []string{"b", "d"} {
+		if !state.PrefixSet[letter] {
+			t.Errorf("Expected %q in PrefixSet, got %v", letter, state.PrefixSet)
+		}
+	}
+	if state.SuffixSet["b"] || state.PrefixSet["n"] {
+		t.Errorf("Unexpected letters in sets: prefix %v, suffix %v", state.PrefixSet, state.SuffixSet)
+	}
+
+	// An embedded letter is accepted by AddLetter
+	newState, _, err := AddLetter(state, dict, "d", "suffix")
+	if err != nil || newState.Answer != "and" {
+		t.Errorf("AddLetter(\"d\") = %q, %v; want \"and\"", newState.Answer, err)
+	}
+}
+
+func TestMultiByteLetters(t *testing.T) {
+	dict := NewWordDictionary([]string{"año", "años", "niño"})
+	state := UpdateSets(WordBuilderState{}, dict)
+	if !state.PrefixSet["a"] || !state.SuffixSet["o"] {
+		t.Fatalf("Expected the sets seeded from the word list, got %v and %v", state.PrefixSet, state.SuffixSet)
+	}
+
+	state, _, err := AddLetter(state, dict, "a", "prefix")
+	for _, letter := range []string{"ñ", "o"} {
+		if state, _, err = AddLetter(state, dict, letter, "suffix"); err != nil {
+			t.Fatalf("Adding %q failed: %v", letter, err)
+		}
+	}
+	if state.Answer != "año" || !state.IsValidWord {
+		t.Fatalf("Expected 'año', got %q", state.Answer)
+	}
+	if _, _, err := AddLetter(state, dict, "x", "suffix"); err == nil {
+		t.Error("Expected an error for a letter outside the alphabet")
+	}
+
+	// Indexes count letters, not bytes
+	state, _, err = RemoveLetter(state, dict, 2)
+	if err != nil || state.Answer != "añ" {
+		t.Fatalf("Expected 'añ' after removing the last letter, got %q: %v", state.Answer, err)
+	}
+	if _, _, err := RemoveLetter(state, dict, 2); err == nil {
+		t.Error("Expected an error for an index past the last letter")
+	}
+	state, _, err = UndoMove(state, dict)
+	if err != nil || state.Answer != "año" {
+		t.Errorf("Expected undo to restore 'año', got %q: %v", state.Answer, err)
 	}
 }
@@ -11,7 +11,10 @@ type WordList struct {
 	Description string    `json:"description"`
 	Source      string    `json:"source"`
 	FilePath    string    `json:"file_path"`
-	WordCount   int       `json:"word_count"`
+	WordCount   int       `json:"word_count"` // Words left after normalization
+	Alphabet    []string  `json:"alphabet"`   // Letters used by the words, sorted
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Normalization NormalizeOptions `json:"normalization"` // How entries are cleaned up on load
 }
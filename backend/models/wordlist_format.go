@@ -0,0 +1,398 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats a word list can be uploaded in. Each may also be gzipped.
+const (
+	FormatText = "txt"  // One word per line, optionally followed by a tab and its frequency
+	FormatCSV  = "csv"  // Comma-separated columns
+	FormatTSV  = "tsv"  // Tab-separated columns
+	FormatJSON = "json" // An array of words or of objects, or an object keyed by word
+)
+
+// Columns looked for in the header of a CSV or TSV file, or the fields of a
+// JSON object, when the import options don't name them
+var (
+	defaultWordColumn       = "word"
+	defaultFrequencyColumns = []string{"frequency", "count", "rank"}
+)
+
+// ErrUnsupportedFormat is returned for a file whose format can't be told or
+// isn't supported
+var ErrUnsupportedFormat = errors.New("unsupported word list format")
+
+// ImportOptions says how to read an uploaded word list file
+type ImportOptions struct {
+	Format          string // One of the Format constants, empty to go by the file extension
+	WordColumn      string // Header name or 1-based number of the column holding the words
+	FrequencyColumn string // Header name or 1-based number of the column holding the frequencies
+	MaxSize         int64  // Bytes read once decompressed, 0 for no limit
+}
+
+// WordEntry is a word read from a word list file, with its frequency if the
+// file has one
+type WordEntry struct {
+	Word         string
+	Frequency    float64 // A count or a rank, as NormalizeOptions.Frequency says
+	HasFrequency bool
+}
+
+// WordListReader reads the entries of a word list file in one format. Other
+// columns, such as definitions, parts of speech or tags, are skipped.
+type WordListReader interface {
+	ReadEntries(r io.Reader) ([]WordEntry, error)
+}
+
+// NewWordListReader returns the reader for a format, or for the extension of
+// filename when format is empty. A ".gz" extension is looked past.
+func NewWordListReader(filename string, opts ImportOptions) (WordListReader, error) {
+	format := opts.Format
+	if format == "" {
+		ext := strings.ToLower(filepath.Ext(filename))
+		if ext == ".gz" {
+			ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, filepath.Ext(filename))))
+		}
+		format = strings.TrimPrefix(ext, ".")
+	}
+
+	switch format {
+	case FormatText:
+		return textReader{}, nil
+	case FormatCSV:
+		return delimitedReader{comma: ',', wordColumn: opts.WordColumn, frequencyColumn: opts.FrequencyColumn}, nil
+	case FormatTSV:
+		return delimitedReader{comma: '\t', wordColumn: opts.WordColumn, frequencyColumn: opts.FrequencyColumn}, nil
+	case FormatJSON:
+		return jsonReader{wordField: opts.WordColumn, frequencyField: opts.FrequencyColumn}, nil
+	}
+	return nil, fmt.Errorf("%w '%s'", ErrUnsupportedFormat, format)
+}
+
+// ConvertWordList reads an uploaded word list file, gzipped or not, and
+// writes it back as the text word lists are stored in: one entry per line,
+// with the frequency after a tab
+func ConvertWordList(data []byte, filename string, opts ImportOptions) ([]byte, error) {
+	reader, err := NewWordListReader(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if opts.MaxSize > 0 {
+		r = &limitedReader{r: r, left: opts.MaxSize}
+	}
+
+	entries, err := reader.ReadEntries(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		buf.WriteString(entry.Word)
+		if entry.HasFrequency {
+			buf.WriteByte('\t')
+			buf.WriteString(strconv.FormatFloat(entry.Frequency, 'g', -1, 64))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// limitedReader fails once more than left bytes are read, rather than
+// quietly cutting the file short like io.LimitReader
+type limitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, fmt.Errorf("word list is too large once decompressed")
+	}
+	return n, err
+}
+
+// textReader reads one entry per line, as word lists are stored
+type textReader struct{}
+
+func (textReader) ReadEntries(r io.Reader) ([]WordEntry, error) {
+	var entries []WordEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			entries = append(entries, WordEntry{Word: line})
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// delimitedReader reads CSV and TSV files. A first row naming the columns
+// is a header, and columns can be picked by name or number. A file of
+// several columns without a header naming the word or frequency column
+// needs its columns picked; when they are picked by number, the first row is
+// read as words.
+type delimitedReader struct {
+	comma           rune
+	wordColumn      string
+	frequencyColumn string
+}
+
+func (d delimitedReader) ReadEntries(r io.Reader) ([]WordEntry, error) {
+	records := csv.NewReader(r)
+	records.Comma = d.comma
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+	records.LazyQuotes = d.comma == '\t'
+
+	first, err := records.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wordIndex, wordNamed, err := columnIndex(first, d.wordColumn, []string{defaultWordColumn})
+	if err != nil {
+		return nil, err
+	}
+	if wordIndex < 0 {
+		wordIndex = 0
+	}
+	frequencyIndex, frequencyNamed, err := columnIndex(first, d.frequencyColumn, defaultFrequencyColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []WordEntry
+	add := func(record []string) error {
+		if wordIndex >= len(record) {
+			return nil
+		}
+		entry := WordEntry{Word: strings.Join(strings.Fields(record[wordIndex]), " ")}
+		if entry.Word == "" {
+			return nil
+		}
+		if frequencyIndex >= 0 && frequencyIndex < len(record) && strings.TrimSpace(record[frequencyIndex]) != "" {
+			frequency, err := parseFrequency(record[frequencyIndex])
+			if err != nil {
+				line, _ := records.FieldPos(frequencyIndex)
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			entry.Frequency, entry.HasFrequency = frequency, true
+		}
+		entries = append(entries, entry)
+		return nil
+	}
+
+	// A first row naming a column is a header rather than a word. Without
+	// one, a row of several columns may still be a header naming them
+	// otherwise, as in "term,definition", so the columns must be picked.
+	if !wordNamed && !frequencyNamed {
+		if len(first) > 1 && d.wordColumn == "" && d.frequencyColumn == "" {
+			return nil, fmt.Errorf("can't tell which of the %d columns holds the words: name it '%s' in a header, or pick the columns by name or number", len(first), defaultWordColumn)
+		}
+		if err := add(first); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := add(record); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// columnIndex finds the 0-based index of a column given by number or by a
+// name in the header, or of the first default name in the header when none
+// is given. It returns -1 when there is no such column, and whether the
+// column was found by name.
+func columnIndex(header []string, column string, defaults []string) (int, bool, error) {
+	if column != "" {
+		if number, err := strconv.Atoi(column); err == nil {
+			if number < 1 {
+				return 0, false, fmt.Errorf("column numbers start at 1, got %d", number)
+			}
+			return number - 1, false, nil
+		}
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				return i, true, nil
+			}
+		}
+		return 0, false, fmt.Errorf("no column named '%s'", column)
+	}
+	for _, name := range defaults {
+		for i, cell := range header {
+			if strings.EqualFold(strings.TrimSpace(cell), name) {
+				return i, true, nil
+			}
+		}
+	}
+	return -1, false, nil
+}
+
+// parseFrequency reads a count or a rank, which can't be negative
+func parseFrequency(value string) (float64, error) {
+	frequency, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || frequency < 0 {
+		return 0, fmt.Errorf("invalid frequency '%s'", value)
+	}
+	return frequency, nil
+}
+
+// jsonReader reads an array of words or of objects with a word field, or an
+// object keyed by word whose values are frequencies or objects. Entries keep
+// the order of the file.
+type jsonReader struct {
+	wordField      string
+	frequencyField string
+}
+
+func (j jsonReader) ReadEntries(r io.Reader) ([]WordEntry, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var entries []WordEntry
+	add := func(word string, value any) error {
+		entry, ok, err := j.entry(word, value)
+		if err != nil {
+			return err
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+		return nil
+	}
+	switch token {
+	case json.Delim('['):
+		for decoder.More() {
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			if err := add("", value); err != nil {
+				return nil, err
+			}
+		}
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			if err := add(key.(string), value); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected a JSON array or object")
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return entries, nil
+}
+
+// entry reads one value of the file: a word, a frequency keyed by word, or
+// an object. key is the word the value is keyed by, if any.
+func (j jsonReader) entry(key string, value any) (WordEntry, bool, error) {
+	entry := WordEntry{Word: key}
+	switch value := value.(type) {
+	case string:
+		if key == "" {
+			entry.Word = value
+		} else if err := entry.setFrequency(value); err != nil {
+			return entry, false, fmt.Errorf("'%s': %w", key, err)
+		}
+	case json.Number:
+		if key == "" {
+			return entry, false, fmt.Errorf("expected a word, got %s", value)
+		}
+		if err := entry.setFrequency(value); err != nil {
+			return entry, false, fmt.Errorf("'%s': %w", key, err)
+		}
+	case map[string]any:
+		if key == "" {
+			wordField := j.wordField
+			if wordField == "" {
+				wordField = defaultWordColumn
+			}
+			word, ok := value[wordField].(string)
+			if !ok {
+				return entry, false, fmt.Errorf("object without a '%s' field", wordField)
+			}
+			entry.Word = word
+		}
+		frequencyFields := defaultFrequencyColumns
+		if j.frequencyField != "" {
+			frequencyFields = []string{j.frequencyField}
+		}
+		for _, field := range frequencyFields {
+			if frequency, ok := value[field]; ok && frequency != nil {
+				if err := entry.setFrequency(frequency); err != nil {
+					return entry, false, fmt.Errorf("'%s': %w", entry.Word, err)
+				}
+				break
+			}
+		}
+	case nil:
+	default:
+		return entry, false, fmt.Errorf("unexpected JSON value %v", value)
+	}
+
+	entry.Word = strings.Join(strings.Fields(entry.Word), " ")
+	return entry, entry.Word != "", nil
+}
+
+// setFrequency sets the frequency from a JSON number or numeric string
+func (e *WordEntry) setFrequency(value any) error {
+	var err error
+	switch value := value.(type) {
+	case json.Number:
+		e.Frequency, err = parseFrequency(value.String())
+	case string:
+		e.Frequency, err = parseFrequency(value)
+	default:
+		return fmt.Errorf("invalid frequency %v", value)
+	}
+	e.HasFrequency = err == nil
+	return err
+}
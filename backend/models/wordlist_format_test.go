@@ -0,0 +1,85 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertWordList(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		opts     ImportOptions
+		want     string
+	}{
+		{"text", "words.txt", "apple\n\n banana\t12 \n", ImportOptions{}, "apple\nbanana\t12\n"},
+		{"csv with header", "words.csv", "word,definition,frequency\napple,a fruit,12\n\"ice  cream\",\"cold, sweet\",\nbanana,,3\n", ImportOptions{},
+			"apple\t12\nice cream\nbanana\t3\n"},
+		{"csv without header", "words.csv", "apple\nbanana\n", ImportOptions{}, "apple\nbanana\n"},
+		{"csv columns by number", "words.csv", "apple,12\nbanana,3\n", ImportOptions{WordColumn: "1"}, "apple\nbanana\n"},
+		{"csv by number", "words.csv", "apple,12\nbanana,3\n", ImportOptions{FrequencyColumn: "2"}, "apple\t12\nbanana\t3\n"},
+		{"tsv by name", "words.TSV", "pos\tlemma\tfreq\nnoun\tapple\t12\n", ImportOptions{WordColumn: "Lemma", FrequencyColumn: "freq"}, "apple\t12\n"},
+		{"format override", "upload.dat", "lemma\tcount\napple\t12\n", ImportOptions{Format: FormatTSV, WordColumn: "lemma"}, "apple\t12\n"},
+		{"json array", "words.json", `["apple", "banana"]`, ImportOptions{}, "apple\nbanana\n"},
+		{"json objects", "words.json", `[{"word": "apple", "rank": 1, "tags": ["food"]}, {"word": "banana"}]`, ImportOptions{}, "apple\t1\nbanana\n"},
+		{"json fields", "words.json", `[{"lemma": "apple", "n": "12"}]`, ImportOptions{WordColumn: "lemma", FrequencyColumn: "n"}, "apple\t12\n"},
+		{"json keyed by word", "words.json", `{"zebra": 3, "apple": {"frequency": 12}, "banana": null}`, ImportOptions{}, "zebra\t3\napple\t12\nbanana\n"},
+	}
+	for _, tt := range tests {
+		got, err := ConvertWordList([]byte(tt.data), tt.filename, tt.opts)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: ConvertWordList() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestConvertGzippedWordList(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("word,count\napple,12\n"))
+	gz.Close()
+
+	got, err := ConvertWordList(buf.Bytes(), "words.csv.gz", ImportOptions{})
+	if err != nil || string(got) != "apple\t12\n" {
+		t.Errorf("ConvertWordList() = %q, %v", got, err)
+	}
+
+	if _, err := ConvertWordList(buf.Bytes(), "words.csv.gz", ImportOptions{MaxSize: 8}); err == nil {
+		t.Error("Expected an error past MaxSize")
+	}
+}
+
+func TestConvertWordListErrors(t *testing.T) {
+	tests := []struct {
+		filename string
+		data     string
+		opts     ImportOptions
+		want     string
+	}{
+		{"words.csv", "word,count\napple,many\n", ImportOptions{}, "line 2: invalid frequency 'many'"},
+		{"words.csv", "word,count\napple,-1\n", ImportOptions{}, "invalid frequency"},
+		{"words.csv", "apple\n", ImportOptions{WordColumn: "lemma"}, "no column named 'lemma'"},
+		{"words.csv", "apple\n", ImportOptions{WordColumn: "0"}, "column numbers start at 1"},
+		{"words.csv", "term,definition\napple,a fruit\n", ImportOptions{}, "can't tell which of the 2 columns holds the words"},
+		{"words.tsv", "apple\t12\n", ImportOptions{}, "can't tell which of the 2 columns holds the words"},
+		{"words.json", `{"apple": true}`, ImportOptions{}, "unexpected JSON value"},
+		{"words.json", `[{"lemma": "apple"}]`, ImportOptions{}, "object without a 'word' field"},
+		{"words.json", `"apple"`, ImportOptions{}, "expected a JSON array or object"},
+		{"words.json", `["apple"`, ImportOptions{}, "invalid JSON"},
+	}
+	for _, tt := range tests {
+		if _, err := ConvertWordList([]byte(tt.data), tt.filename, tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ConvertWordList(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+
+	for _, filename := range []string{"words.xlsx", "words.gz", "words"} {
+		if _, err := NewWordListReader(filename, ImportOptions{}); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("NewWordListReader(%q) error = %v, want ErrUnsupportedFormat", filename, err)
+		}
+	}
+}
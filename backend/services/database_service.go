@@ -2,6 +2,8 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"wordbuilder/models"
@@ -25,6 +27,10 @@ func NewDatabaseService(dbPath string) (*DatabaseService, error) {
 		return nil, err
 	}
 
+	// SQLite allows a single writer; sharing one connection queues concurrent
+	// requests instead of failing them with "database is locked"
+	db.SetMaxOpenConns(1)
+
 	service := &DatabaseService{DB: db}
 	err = service.InitTables()
 	if err != nil {
@@ -56,24 +62,129 @@ func (s *DatabaseService) InitTables() error {
 			updated_at TIMESTAMP NOT NULL
 		);`
 
+	// Game sessions, stored as a JSON snapshot of the word builder state
+	sessionsTable := `
+		CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			state TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`
+
+	// Results of sessions playing the daily puzzle, kept after the session ends
+	dailyResultsTable := `
+		CREATE TABLE IF NOT EXISTS daily_results (
+			session_id TEXT PRIMARY KEY,
+			puzzle_date TEXT NOT NULL,
+			word_list_id INTEGER NOT NULL,
+			found TEXT NOT NULL,
+			target_count INTEGER NOT NULL,
+			score INTEGER NOT NULL,
+			completed_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL
+		);`
+
 	_, err := s.DB.Exec(wordListTable)
 	if err != nil {
 		return err
 	}
 
 	_, err = s.DB.Exec(settingsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(sessionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(dailyResultsTable)
+	if err != nil {
+		return err
+	}
+
+	// Databases created before word lists had normalization options or
+	// kept their alphabet
+	if err := s.addColumnIfMissing("word_lists", "normalization", "TEXT"); err != nil {
+		return err
+	}
+	return s.addColumnIfMissing("word_lists", "alphabet", "TEXT")
+}
+
+// addColumnIfMissing adds a column to a table created by an earlier version
+func (s *DatabaseService) addColumnIfMissing(table, column, definition string) error {
+	rows, err := s.DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, kind       string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = s.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
+// scanWordList reads a word list row selected with wordListColumns
+func scanWordList(row interface{ Scan(...any) error }) (*models.WordList, error) {
+	var list models.WordList
+	var normalization, alphabet sql.NullString
+	if err := row.Scan(&list.ID, &list.Name, &list.Description, &list.Source, &list.FilePath, &list.WordCount, &list.CreatedAt, &list.UpdatedAt, &normalization, &alphabet); err != nil {
+		return nil, err
+	}
+	// Lists saved before alphabets were kept have none until they are loaded
+	if alphabet.Valid && alphabet.String != "" {
+		if err := json.Unmarshal([]byte(alphabet.String), &list.Alphabet); err != nil {
+			return nil, fmt.Errorf("invalid alphabet of word list %d: %w", list.ID, err)
+		}
+	}
+	// Lists saved before normalization options existed get the defaults
+	if normalization.Valid && normalization.String != "" {
+		if err := json.Unmarshal([]byte(normalization.String), &list.Normalization); err != nil {
+			return nil, fmt.Errorf("invalid normalization options of word list %d: %w", list.ID, err)
+		}
+	}
+	return &list, nil
+}
+
+// wordListColumns are the columns scanWordList reads
+const wordListColumns = "id, name, description, source, file_path, word_count, created_at, updated_at, normalization, alphabet"
+
 // InsertWordList adds a new word list to the database
 func (s *DatabaseService) InsertWordList(list *models.WordList) (int, error) {
 	now := time.Now()
 	list.CreatedAt = now
 	list.UpdatedAt = now
 
+	normalization, err := json.Marshal(list.Normalization)
+	if err != nil {
+		return 0, err
+	}
+	alphabet, err := json.Marshal(list.Alphabet)
+	if err != nil {
+		return 0, err
+	}
+
 	result, err := s.DB.Exec(
-		"INSERT INTO word_lists (name, description, source, file_path, word_count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		list.Name, list.Description, list.Source, list.FilePath, list.WordCount, list.CreatedAt, list.UpdatedAt,
+		"INSERT INTO word_lists (name, description, source, file_path, word_count, created_at, updated_at, normalization, alphabet) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		list.Name, list.Description, list.Source, list.FilePath, list.WordCount, list.CreatedAt, list.UpdatedAt, string(normalization), string(alphabet),
 	)
 	if err != nil {
 		return 0, err
@@ -90,22 +201,12 @@ func (s *DatabaseService) InsertWordList(list *models.WordList) (int, error) {
 
 // GetWordList retrieves a word list by ID
 func (s *DatabaseService) GetWordList(id int) (*models.WordList, error) {
-	var list models.WordList
-	err := s.DB.QueryRow(
-		"SELECT id, name, description, source, file_path, word_count, created_at, updated_at FROM word_lists WHERE id = ?",
-		id,
-	).Scan(&list.ID, &list.Name, &list.Description, &list.Source, &list.FilePath, &list.WordCount, &list.CreatedAt, &list.UpdatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &list, nil
+	return scanWordList(s.DB.QueryRow("SELECT "+wordListColumns+" FROM word_lists WHERE id = ?", id))
 }
 
 // GetAllWordLists retrieves all word lists
 func (s *DatabaseService) GetAllWordLists() ([]*models.WordList, error) {
-	rows, err := s.DB.Query("SELECT id, name, description, source, file_path, word_count, created_at, updated_at FROM word_lists ORDER BY updated_at DESC")
+	rows, err := s.DB.Query("SELECT " + wordListColumns + " FROM word_lists ORDER BY updated_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -113,11 +214,11 @@ func (s *DatabaseService) GetAllWordLists() ([]*models.WordList, error) {
 
 	var lists []*models.WordList
 	for rows.Next() {
-		var list models.WordList
-		if err := rows.Scan(&list.ID, &list.Name, &list.Description, &list.Source, &list.FilePath, &list.WordCount, &list.CreatedAt, &list.UpdatedAt); err != nil {
+		list, err := scanWordList(rows)
+		if err != nil {
 			return nil, err
 		}
-		lists = append(lists, &list)
+		lists = append(lists, list)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -131,14 +232,34 @@ func (s *DatabaseService) GetAllWordLists() ([]*models.WordList, error) {
 func (s *DatabaseService) UpdateWordList(list *models.WordList) error {
 	list.UpdatedAt = time.Now()
 
-	_, err := s.DB.Exec(
-		"UPDATE word_lists SET name = ?, description = ?, source = ?, file_path = ?, word_count = ?, updated_at = ? WHERE id = ?",
-		list.Name, list.Description, list.Source, list.FilePath, list.WordCount, list.UpdatedAt, list.ID,
+	normalization, err := json.Marshal(list.Normalization)
+	if err != nil {
+		return err
+	}
+	alphabet, err := json.Marshal(list.Alphabet)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(
+		"UPDATE word_lists SET name = ?, description = ?, source = ?, file_path = ?, word_count = ?, updated_at = ?, normalization = ?, alphabet = ? WHERE id = ?",
+		list.Name, list.Description, list.Source, list.FilePath, list.WordCount, list.UpdatedAt, string(normalization), string(alphabet), list.ID,
 	)
 
 	return err
 }
 
+// SetWordListAlphabet records the alphabet of a word list saved before
+// alphabets were kept, leaving when it was last updated alone
+func (s *DatabaseService) SetWordListAlphabet(id int, alphabet []string) error {
+	data, err := json.Marshal(alphabet)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("UPDATE word_lists SET alphabet = ? WHERE id = ?", string(data), id)
+	return err
+}
+
 // DeleteWordList removes a word list by ID
 func (s *DatabaseService) DeleteWordList(id int) error {
 	_, err := s.DB.Exec("DELETE FROM word_lists WHERE id = ?", id)
@@ -185,6 +306,101 @@ func (s *DatabaseService) SaveSetting(key, value string) error {
 	return err
 }
 
+// SaveSession saves or updates the state of a game session
+func (s *DatabaseService) SaveSession(sessionID string, state *models.WordBuilderState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// Stored in UTC so idle cutoffs compare correctly as text
+	now := time.Now().UTC()
+	_, err = s.DB.Exec(
+		`INSERT INTO sessions (id, state, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET state = excluded.state, updated_at = excluded.updated_at`,
+		sessionID, string(data), now, now,
+	)
+	return err
+}
+
+// GetSession retrieves the saved state of a game session by ID
+func (s *DatabaseService) GetSession(sessionID string) (*models.WordBuilderState, error) {
+	var data string
+	err := s.DB.QueryRow("SELECT state FROM sessions WHERE id = ?", sessionID).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	var state models.WordBuilderState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DeleteSession removes a game session by ID
+func (s *DatabaseService) DeleteSession(sessionID string) error {
+	_, err := s.DB.Exec("DELETE FROM sessions WHERE id = ?", sessionID)
+	return err
+}
+
+// DeleteSessionsIdleSince removes game sessions not updated since the cutoff
+func (s *DatabaseService) DeleteSessionsIdleSince(cutoff time.Time) (int64, error) {
+	result, err := s.DB.Exec("DELETE FROM sessions WHERE updated_at < ?", cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SaveDailyResult saves or updates the result of a session on a daily puzzle
+func (s *DatabaseService) SaveDailyResult(result *models.DailyResult) error {
+	found, err := json.Marshal(result.Found)
+	if err != nil {
+		return err
+	}
+
+	// The first completion time is kept
+	_, err = s.DB.Exec(
+		`INSERT INTO daily_results (session_id, puzzle_date, word_list_id, found, target_count, score, completed_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET found = excluded.found, score = excluded.score,
+			completed_at = COALESCE(daily_results.completed_at, excluded.completed_at), updated_at = excluded.updated_at`,
+		result.SessionID, result.Date, result.WordListID, string(found), result.TargetCount, result.Score, result.CompletedAt, time.Now().UTC(),
+	)
+	return err
+}
+
+// GetDailyResult retrieves the result of a session on its daily puzzle
+func (s *DatabaseService) GetDailyResult(sessionID string) (*models.DailyResult, error) {
+	var result models.DailyResult
+	var found string
+	var completedAt sql.NullTime
+	err := s.DB.QueryRow(
+		"SELECT session_id, puzzle_date, word_list_id, found, target_count, score, completed_at FROM daily_results WHERE session_id = ?",
+		sessionID,
+	).Scan(&result.SessionID, &result.Date, &result.WordListID, &found, &result.TargetCount, &result.Score, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(found), &result.Found); err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		result.CompletedAt = &completedAt.Time
+	}
+	return &result, nil
+}
+
+// GetDailyStats counts the sessions that played and completed a daily puzzle
+func (s *DatabaseService) GetDailyStats(date string, wordListID int) (players int, completed int, err error) {
+	err = s.DB.QueryRow(
+		"SELECT COUNT(*), COUNT(completed_at) FROM daily_results WHERE puzzle_date = ? AND word_list_id = ?",
+		date, wordListID,
+	).Scan(&players, &completed)
+	return players, completed, err
+}
+
 // Close closes the database connection
 func (s *DatabaseService) Close() error {
 	return s.DB.Close()
@@ -3,15 +3,13 @@ package services
 import (
 	"bufio"
 	"os"
-	"strings"
 
 	"wordbuilder/models"
-	utils "wordbuilder/utils"
 )
 
 // DictionaryService provides operations for dictionary functionality
 type DictionaryService struct {
-	// Any necessary fields
+	Options models.DictionaryOptions // How the dictionaries created hold their words
 }
 
 // NewDictionaryService creates a new dictionary service
@@ -19,45 +17,47 @@ func NewDictionaryService() *DictionaryService {
 	return &DictionaryService{}
 }
 
-// LoadWordList loads the dictionary from a file
+// LoadWordList loads the dictionary from a file with the default normalization
 func (s *DictionaryService) LoadWordList(filename string) ([]string, error) {
-	// Move this function from main.go
+	return s.LoadNormalizedWordList(filename, models.NormalizeOptions{})
+}
+
+// LoadNormalizedWordList loads the dictionary from a file, cleaning up each
+// line with the given options
+func (s *DictionaryService) LoadNormalizedWordList(filename string, opts models.NormalizeOptions) ([]string, error) {
+	words, _, err := s.LoadWeightedWordList(filename, opts)
+	return words, err
+}
+
+// LoadWeightedWordList loads the dictionary from a file like
+// LoadNormalizedWordList, along with how common the words are when the file
+// has a frequency column
+func (s *DictionaryService) LoadWeightedWordList(filename string, opts models.NormalizeOptions) ([]string, map[string]float64, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
-	var words []string
+	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		word := strings.TrimSpace(strings.ToLower(scanner.Text()))
-		if word != "" {
-			words = append(words, word)
-		}
+		lines = append(lines, scanner.Text())
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return words, nil
+	words, frequencies := models.NormalizeWeightedWords(lines, opts)
+	return words, frequencies, nil
 }
 
 // CreateDictionary creates a new WordDictionary from a word list
 func (s *DictionaryService) CreateDictionary(wordList []string) *models.WordDictionary {
-	dict := &models.WordDictionary{
-		WordSet:     make(map[string]bool),
-		ForwardTrie: models.NewTrie(),
-		ReverseTrie: models.NewTrie(),
-		WordList:    make([]string, 0, len(wordList)),
-	}
-
-	for _, word := range wordList {
-		word = strings.ToLower(word)
-		dict.WordSet[word] = true
-		dict.ForwardTrie.Insert(word)
-		dict.ReverseTrie.Insert(utils.ReverseString(word))
-		dict.WordList = append(dict.WordList, word)
-	}
+	return s.CreateWeightedDictionary(wordList, nil)
+}
 
-	return dict
+// CreateWeightedDictionary creates a new WordDictionary from a word list and
+// how common its words are, nil if unknown
+func (s *DictionaryService) CreateWeightedDictionary(wordList []string, frequencies map[string]float64) *models.WordDictionary {
+	return models.NewWeightedWordDictionary(wordList, frequencies, s.Options)
 }
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"wordbuilder/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrRoomNotFound is returned when a room ID is unknown
+var ErrRoomNotFound = errors.New("room not found")
+
+const (
+	// DefaultRoomTTL is how long a room without connected players is kept
+	DefaultRoomTTL = time.Hour
+	// roomEventBuffer is how many events a player may fall behind before
+	// their connection is dropped
+	roomEventBuffer = 16
+)
+
+// RoomEvent is pushed to the players of a room
+type RoomEvent struct {
+	Type    string                 `json:"type"` // "state" after every change
+	Room    map[string]interface{} `json:"room"`
+	Message string                 `json:"message,omitempty"`
+}
+
+// RoomService hosts multiplayer rooms and pushes every change to the players
+// connected to them.
+//
+// Like sessions, each room has its own lock, so moves in different rooms
+// don't wait on each other. Lock order is s.mu then room.mu.
+type RoomService struct {
+	WordBuilderService *WordBuilderService // Resolves the dictionary a room plays with
+	RoomTTL            time.Duration       // Rooms nobody is connected to are removed after this, 0 disables expiry
+
+	mu    sync.Mutex
+	rooms map[string]*room
+}
+
+// room pairs a room state with the players listening to it
+type room struct {
+	mu           sync.Mutex
+	state        models.RoomState
+	dictionary   *models.WordDictionary
+	subscribers  map[string]chan RoomEvent // By session ID
+	lastActivity time.Time
+}
+
+// NewRoomService creates a new service instance
+func NewRoomService(wordBuilderService *WordBuilderService) *RoomService {
+	return &RoomService{
+		WordBuilderService: wordBuilderService,
+		RoomTTL:            DefaultRoomTTL,
+		rooms:              make(map[string]*room),
+	}
+}
+
+// CreateRoom opens a room playing with a word list, or with the default
+// dictionary when wordListID is 0
+func (s *RoomService) CreateRoom(wordListID int) (string, models.RoomState, error) {
+	dictionary, wordListID, err := s.WordBuilderService.Dictionary(wordListID)
+	if err != nil {
+		return "", models.RoomState{}, err
+	}
+
+	roomID := uuid.New().String()
+	state := models.NewRoomState(newState(dictionary, wordListID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rooms[roomID] = &room{
+		state:        state,
+		dictionary:   dictionary,
+		subscribers:  make(map[string]chan RoomEvent),
+		lastActivity: time.Now(),
+	}
+	return roomID, state, nil
+}
+
+// GetRoom retrieves a copy of a room's state by ID
+func (s *RoomService) GetRoom(roomID string) (models.RoomState, bool) {
+	r, exists := s.lookup(roomID)
+	if !exists {
+		return models.RoomState{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state, true
+}
+
+// Join connects a player to a room and returns their seat and the channel
+// their events arrive on. Joining again with the same session ID takes the
+// seat back, closing the channel of any earlier connection.
+func (s *RoomService) Join(roomID, sessionID, name string) (<-chan RoomEvent, int, error) {
+	r, exists := s.lookup(roomID)
+	if !exists {
+		return nil, -1, ErrRoomNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, seat, err := models.JoinRoom(r.state, sessionID, name)
+	if err != nil {
+		return nil, -1, err
+	}
+	if previous, exists := r.subscribers[sessionID]; exists {
+		close(previous)
+	}
+	events := make(chan RoomEvent, roomEventBuffer)
+	r.subscribers[sessionID] = events
+
+	r.state = state
+	r.broadcast(fmt.Sprintf("%s joined the room.", state.Players[seat].Name))
+	return events, seat, nil
+}
+
+// Leave disconnects a player. events must be the channel returned by Join, so
+// a connection that was already replaced by a reconnect leaves nothing.
+func (s *RoomService) Leave(roomID, sessionID string, events <-chan RoomEvent) {
+	r, exists := s.lookup(roomID)
+	if !exists {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.subscribers[sessionID]
+	if !exists || (<-chan RoomEvent)(current) != events {
+		return
+	}
+	close(current)
+	delete(r.subscribers, sessionID)
+
+	name := ""
+	if seat := r.state.SeatOf(sessionID); seat >= 0 {
+		name = r.state.Players[seat].Name
+	}
+	r.state = models.LeaveRoom(r.state, sessionID)
+	r.broadcast(fmt.Sprintf("%s left the room.", name))
+}
+
+// Start begins the game in a room
+func (s *RoomService) Start(roomID, sessionID string) (string, error) {
+	return s.update(roomID, func(state models.RoomState, _ *models.WordDictionary) (models.RoomState, string, error) {
+		return models.StartRoom(state, sessionID)
+	})
+}
+
+// Move adds a letter for the player whose turn it is
+func (s *RoomService) Move(roomID, sessionID, letter, position string) (string, error) {
+	return s.update(roomID, func(state models.RoomState, dictionary *models.WordDictionary) (models.RoomState, string, error) {
+		return models.RoomMove(state, dictionary, sessionID, letter, position)
+	})
+}
+
+// ReapIdleRooms removes rooms nobody has been connected to for longer than
+// RoomTTL and returns how many were removed
+func (s *RoomService) ReapIdleRooms(now time.Time) int {
+	if s.RoomTTL <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-s.RoomTTL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reaped := 0
+	for roomID, r := range s.rooms {
+		r.mu.Lock()
+		idle := len(r.subscribers) == 0 && r.lastActivity.Before(cutoff)
+		r.mu.Unlock()
+		if idle {
+			delete(s.rooms, roomID)
+			reaped++
+		}
+	}
+	return reaped
+}
+
+// StartReaper reaps idle rooms every interval until stop is called
+func (s *RoomService) StartReaper(interval time.Duration) (stop func()) {
+	return every(interval, func(now time.Time) {
+		if reaped := s.ReapIdleRooms(now); reaped > 0 {
+			log.Printf("Reaped %d idle rooms", reaped)
+		}
+	})
+}
+
+// update applies a change to a room while holding its lock and pushes the
+// result to its players
+func (s *RoomService) update(roomID string, change func(models.RoomState, *models.WordDictionary) (models.RoomState, string, error)) (string, error) {
+	r, exists := s.lookup(roomID)
+	if !exists {
+		return "", ErrRoomNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, message, err := change(r.state, r.dictionary)
+	if err != nil {
+		return message, err
+	}
+	r.state = state
+	r.broadcast(message)
+	return message, nil
+}
+
+// lookup finds a room by ID
+func (s *RoomService) lookup(roomID string) (*room, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, exists := s.rooms[roomID]
+	return r, exists
+}
+
+// broadcast pushes the room state to every connected player. A player who
+// has fallen too far behind is disconnected rather than stalling the room,
+// and once everyone has had the event, the others are told they left.
+// The caller must hold r.mu.
+func (r *room) broadcast(message string) {
+	r.lastActivity = time.Now()
+	for {
+		event := RoomEvent{Type: "state", Room: models.GetRoomView(r.state), Message: message}
+		var dropped []string
+		for sessionID, events := range r.subscribers {
+			select {
+			case events <- event:
+			default:
+				close(events)
+				delete(r.subscribers, sessionID)
+				dropped = append(dropped, sessionID)
+			}
+		}
+		if len(dropped) == 0 {
+			return
+		}
+
+		var names []string
+		for _, sessionID := range dropped {
+			if seat := r.state.SeatOf(sessionID); seat >= 0 {
+				names = append(names, r.state.Players[seat].Name)
+			}
+			r.state = models.LeaveRoom(r.state, sessionID)
+		}
+		sort.Strings(names)
+		message = fmt.Sprintf("%s lost the connection.", strings.Join(names, ", "))
+	}
+}
@@ -1,33 +1,131 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"wordbuilder/models"
 )
 
-// WordBuilderService handles game state and operations
+var (
+	// ErrSessionNotFound is returned when a session ID is unknown
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidSessionOptions is returned when a session can't be created with the given options
+	ErrInvalidSessionOptions = errors.New("invalid session options")
+
+	// ErrTimeUp is returned for moves made after a timed round has ended
+	ErrTimeUp = errors.New("time is up")
+	// ErrInvalidRack is returned when letters can't make up a rack
+	ErrInvalidRack = errors.New("invalid rack")
+	// ErrWordListNotFound is returned when the dictionary of a word list can't be loaded
+	ErrWordListNotFound = errors.New("word list not found")
+
+	errNoTakeBack = errors.New("moves can't be taken back in this mode")
+	errNoHints    = errors.New("hints are not available in this mode")
+	errNoInsert   = errors.New("letters can only be added at the ends in this mode")
+)
+
+// Game modes a session can be created in
+const (
+	ModeClassic = "classic" // Build words freely
+	ModeGhost   = "ghost"   // Play Superghost against the computer
+	ModeTimed   = "timed"   // Find as many words as possible before the clock runs out
+	ModePuzzle  = "puzzle"  // Build a hidden target word from a seed letter
+	ModeDaily   = "daily"   // Find the words of the puzzle of the day
+	ModeRack    = "rack"    // Build words from a finite rack of letter tiles
+)
+
+// SessionOptions configures a new game session
+type SessionOptions struct {
+	WordListID int           // Word list to play with, 0 for the default dictionary
+	Mode       string        // One of the Mode constants, empty for classic
+	Difficulty string        // Computer difficulty in ghost mode, puzzle difficulty in puzzle mode
+	Duration   time.Duration // Length of a timed round, implies timed mode for classic games
+	MinLength  int           // Shortest puzzle target, 0 for the difficulty's default
+	MaxLength  int           // Longest puzzle target, 0 for the difficulty's default
+	Date       string        // Day of a daily puzzle as YYYY-MM-DD, empty for today
+
+	Rack         string         // Tiles of a rack given by the teacher, drawn at random when empty
+	RackSize     int            // Tiles to draw, 0 for models.DefaultRackSize
+	Distribution map[string]int // Tiles per letter to draw from, nil for the default
+}
+
+const (
+	// DefaultSessionTTL is how long a session may sit idle before it is reaped
+	DefaultSessionTTL = 24 * time.Hour
+	// DefaultMaxSessions bounds the number of sessions held in memory
+	DefaultMaxSessions = 10000
+)
+
+// WordBuilderService handles game state and operations.
+//
+// The service owns every session: callers get copies of the state and go
+// through the service methods to change it. mu guards the default dictionary
+// and the session map, while each session has its own lock so moves on
+// different sessions can run in parallel.
+//
+// Every session is bound to the dictionary it was started with, so switching
+// the default word list only affects sessions created afterwards.
 type WordBuilderService struct {
-	Dictionary *models.WordDictionary
-	Sessions   map[string]*models.WordBuilderState
-	// or models.WordBuilderState if you don't want pointers
+	DBService       *DatabaseService // Optional, persists sessions across restarts
+	WordListService *WordListService // Optional, resolves the word list a session is bound to
+	SessionTTL      time.Duration    // Idle sessions older than this are removed, 0 disables expiry
+	MaxSessions     int              // Sessions kept in memory before the least active is evicted, 0 for no limit; needs DBService
+	Scorer          models.Scorer    // Scores the words of classic games, nil disables scoring
+
+	mu                sync.RWMutex
+	dictionary        *models.WordDictionary // Default for sessions started without a word list
+	defaultWordListID int
+	sessions          map[string]*session
+
+	dailyMu      sync.Mutex
+	dailyPuzzles map[string]dailyPuzzle // By date and word list ID
+}
+
+// dailyPuzzle caches a generated daily puzzle along with the dictionary it
+// was generated from, so an updated word list gets a fresh puzzle
+type dailyPuzzle struct {
+	puzzle     models.DailyPuzzle
+	dictionary *models.WordDictionary
+}
+
+// maxCachedDailyPuzzles bounds the daily puzzle cache, which only ever needs
+// a day or two per word list
+const maxCachedDailyPuzzles = 256
+
+// session pairs a game state with the lock serializing moves on it
+type session struct {
+	mu         sync.Mutex
+	state      models.WordBuilderState
+	dictionary *models.WordDictionary
+	removed    bool // Set once the session is deleted, so in-flight moves don't resurrect it
+	evicted    bool // Set once the session is dropped from memory, so in-flight moves load it again
 }
 
 // NewWordBuilderService creates a new service instance
-func NewWordBuilderService(dictionary *models.WordDictionary) *WordBuilderService {
+func NewWordBuilderService(dictionary *models.WordDictionary, wordListID int, dbService *DatabaseService, wordListService *WordListService) *WordBuilderService {
 	return &WordBuilderService{
-		Dictionary: dictionary,
-		Sessions:   make(map[string]*models.WordBuilderState),
+		DBService:         dbService,
+		WordListService:   wordListService,
+		SessionTTL:        DefaultSessionTTL,
+		MaxSessions:       DefaultMaxSessions,
+		Scorer:            models.NewDefaultScorer(),
+		dictionary:        dictionary,
+		defaultWordListID: wordListID,
+		sessions:          make(map[string]*session),
+		dailyPuzzles:      make(map[string]dailyPuzzle),
 	}
 }
 
-// CreateSession initializes a new game session
-func (s *WordBuilderService) CreateSession(sessionID string, dictService *DictionaryService) *models.WordBuilderState {
-	// Safety check - ensure dictionary exists
-	if s.Dictionary == nil {
-		wordList, _ := dictService.LoadWordList("words.txt")
-		s.Dictionary = dictService.CreateDictionary(wordList)
-	}
-
-	// Initialize the state
+// newState creates a fresh state with the sets initialized from the dictionary
+func newState(dictionary *models.WordDictionary, wordListID int) models.WordBuilderState {
 	state := models.WordBuilderState{
 		Answer:           "",
 		PrefixSet:        make(map[string]bool),
@@ -35,58 +133,752 @@ func (s *WordBuilderService) CreateSession(sessionID string, dictService *Dictio
 		Step:             0,
 		IsValidWord:      false,
 		ValidCompletions: []string{},
-		Suggestion:       "",
+		WordListID:       wordListID,
+		LastActivity:     time.Now(),
 	}
 	// Initialize sets using the pure function
-	state = models.UpdateSets(state, s.Dictionary)
-	s.Sessions[sessionID] = &state
-	return &state
+	return models.UpdateSets(state, dictionary)
+}
+
+// applyOptions sets up the game mode on a fresh state
+func (s *WordBuilderService) applyOptions(state models.WordBuilderState, dictionary *models.WordDictionary, opts SessionOptions) (models.WordBuilderState, error) {
+	mode := opts.Mode
+	if opts.Duration != 0 && (mode == "" || mode == ModeClassic) {
+		mode = ModeTimed
+	}
+
+	switch mode {
+	case "", ModeClassic:
+		return state, nil
+	case ModeTimed:
+		state, err := models.StartTimedRound(state, opts.Duration, time.Now())
+		if err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		return state, nil
+	case ModeGhost:
+		if opts.Duration != 0 {
+			return state, fmt.Errorf("%w: ghost games are not timed", ErrInvalidSessionOptions)
+		}
+		state, err := models.StartGhostGame(state, opts.Difficulty)
+		if err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		return state, nil
+	case ModePuzzle:
+		if opts.Duration != 0 {
+			return state, fmt.Errorf("%w: puzzles are not timed", ErrInvalidSessionOptions)
+		}
+		puzzle, err := models.GeneratePuzzle(dictionary, models.PuzzleOptions{
+			Difficulty: opts.Difficulty,
+			MinLength:  opts.MinLength,
+			MaxLength:  opts.MaxLength,
+		}, rand.New(rand.NewSource(time.Now().UnixNano())))
+		if err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		return models.StartPuzzle(state, dictionary, puzzle), nil
+	case ModeDaily:
+		if opts.Duration != 0 {
+			return state, fmt.Errorf("%w: daily puzzles are not timed", ErrInvalidSessionOptions)
+		}
+		date := opts.Date
+		if date == "" {
+			date = Today()
+		}
+		puzzle, err := s.dailyPuzzle(dictionary, date, state.WordListID)
+		if err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		return models.StartDailyPuzzle(state, dictionary, puzzle), nil
+	case ModeRack:
+		if opts.Duration != 0 {
+			return state, fmt.Errorf("%w: rack games are not timed", ErrInvalidSessionOptions)
+		}
+		// Checked before drawing, which fills a bag with every tile
+		if err := models.ValidateDistribution(opts.Distribution); err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		rack, err := newRack(dictionary, opts)
+		if err != nil {
+			return state, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+		return models.StartRack(state, rack), nil
+	default:
+		return state, fmt.Errorf("%w: unknown mode '%s'", ErrInvalidSessionOptions, opts.Mode)
+	}
+}
+
+// optionsOf recovers the options a session was created with, so a reset
+// starts the same kind of game
+func optionsOf(state models.WordBuilderState) SessionOptions {
+	opts := SessionOptions{WordListID: state.WordListID}
+	if state.Ghost != nil {
+		opts.Mode = ModeGhost
+		opts.Difficulty = state.Ghost.Difficulty
+	}
+	if state.Timer != nil {
+		opts.Mode = ModeTimed
+		opts.Duration = state.Timer.Duration
+	}
+	if state.Puzzle != nil {
+		opts.Mode = ModePuzzle
+		opts.Difficulty = state.Puzzle.Difficulty
+		opts.MinLength = state.Puzzle.MinLength
+		opts.MaxLength = state.Puzzle.MaxLength
+	}
+	if state.Daily != nil {
+		opts.Mode = ModeDaily
+		opts.Date = state.Daily.Date
+	}
+	if state.Rack != nil {
+		// A drawn rack is drawn again, while the teacher's rack stays
+		opts.Mode = ModeRack
+		opts.RackSize = len(state.Rack.Tiles)
+		opts.Distribution = state.Rack.Distribution
+		if state.Rack.Supplied {
+			opts.Rack = strings.Join(state.Rack.Tiles, "")
+		}
+	}
+	return opts
+}
+
+// newRack takes the tiles of a rack from the options, or draws them
+func newRack(dictionary *models.WordDictionary, opts SessionOptions) (models.RackState, error) {
+	if opts.Rack != "" {
+		tiles, err := models.ParseRack(dictionary, opts.Rack)
+		if err != nil {
+			return models.RackState{}, err
+		}
+		return models.RackState{Tiles: tiles, Supplied: true}, nil
+	}
+
+	size := opts.RackSize
+	if size == 0 {
+		size = models.DefaultRackSize
+	}
+	tiles, err := models.DrawRack(dictionary, opts.Distribution, size, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return models.RackState{}, err
+	}
+	return models.RackState{Tiles: tiles, Distribution: opts.Distribution}, nil
+}
+
+// CreateSession initializes a new game session bound to a word list, or to
+// the default dictionary when no word list is given
+func (s *WordBuilderService) CreateSession(sessionID string, opts SessionOptions, dictService *DictionaryService) (models.WordBuilderState, error) {
+	wordListID := opts.WordListID
+	var dictionary *models.WordDictionary
+	if wordListID != 0 {
+		var err error
+		dictionary, err = s.loadDictionary(wordListID)
+		if err != nil {
+			return models.WordBuilderState{}, err
+		}
+	} else {
+		dictionary, wordListID = s.defaultDictionary(dictService)
+	}
+
+	// Setting up the mode may search the dictionary, so it happens before
+	// taking the lock
+	state, err := s.applyOptions(newState(dictionary, wordListID), dictionary, opts)
+	if err != nil {
+		return models.WordBuilderState{}, err
+	}
+
+	// The session is locked before it is shared, so moves on it wait for it
+	// to be written to the database, which happens once the service is
+	// unlocked
+	sess := &session{state: state, dictionary: dictionary}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	s.mu.Lock()
+	if s.MaxSessions > 0 && len(s.sessions) >= s.MaxSessions {
+		s.evictLeastActive()
+	}
+	s.sessions[sessionID] = sess
+	s.mu.Unlock()
+
+	s.persist(sessionID, &state)
+	return state, nil
+}
+
+// defaultDictionary returns the default dictionary and its word list ID,
+// falling back to the bundled word list when none is loaded
+func (s *WordBuilderService) defaultDictionary(dictService *DictionaryService) (*models.WordDictionary, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Safety check - ensure dictionary exists
+	if s.dictionary == nil {
+		wordList, frequencies, _ := dictService.LoadWeightedWordList("words.txt", models.NormalizeOptions{})
+		s.dictionary = dictService.CreateWeightedDictionary(wordList, frequencies)
+	}
+	return s.dictionary, s.defaultWordListID
+}
+
+// GetSession retrieves a copy of a session's state by ID
+func (s *WordBuilderService) GetSession(sessionID string) (models.WordBuilderState, bool) {
+	sess, exists := s.acquire(sessionID)
+	if !exists {
+		return models.WordBuilderState{}, false
+	}
+	defer sess.mu.Unlock()
+	return sess.state, true
+}
+
+// DeleteSession ends a session and removes it from memory and the database
+func (s *WordBuilderService) DeleteSession(sessionID string) bool {
+	sess, exists := s.acquire(sessionID)
+	if !exists {
+		return false
+	}
+	defer sess.mu.Unlock()
+	s.remove(sessionID, sess)
+	return true
+}
+
+// ReapIdleSessions removes sessions that have been idle for longer than
+// SessionTTL and returns how many were removed from memory
+func (s *WordBuilderService) ReapIdleSessions(now time.Time) int {
+	if s.SessionTTL <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-s.SessionTTL)
+
+	s.mu.Lock()
+	var reaped []string
+	for sessionID, sess := range s.sessions {
+		// A session whose lock is held is in use, so it isn't idle
+		if !sess.mu.TryLock() {
+			continue
+		}
+		if sess.state.LastActivity.Before(cutoff) {
+			sess.removed = true
+			delete(s.sessions, sessionID)
+			reaped = append(reaped, sessionID)
+		}
+		sess.mu.Unlock()
+	}
+	s.mu.Unlock()
+
+	if s.DBService != nil {
+		for _, sessionID := range reaped {
+			if err := s.DBService.DeleteSession(sessionID); err != nil {
+				log.Printf("Failed to delete session %s: %v", sessionID, err)
+			}
+		}
+		// Sessions that were never loaded since the last restart only live in the database
+		if _, err := s.DBService.DeleteSessionsIdleSince(cutoff); err != nil {
+			log.Printf("Failed to delete idle sessions: %v", err)
+		}
+	}
+	return len(reaped)
+}
+
+// StartReaper reaps idle sessions every interval until stop is called
+func (s *WordBuilderService) StartReaper(interval time.Duration) (stop func()) {
+	return every(interval, func(now time.Time) {
+		if reaped := s.ReapIdleSessions(now); reaped > 0 {
+			log.Printf("Reaped %d idle sessions", reaped)
+		}
+	})
+}
+
+// every calls fn with the current time every interval until stop is called
+func every(interval time.Duration, fn func(now time.Time)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				fn(now)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// ResetSession clears the answer of a session to start a new word. The score
+// and the hints taken carry over, as they accumulate across the words of a
+// session. A timed session starts a new round from a score of 0, as the score
+// is what the round was played for, but only while its clock runs, so the
+// final score of a round stands. A daily puzzle keeps the words found.
+func (s *WordBuilderService) ResetSession(sessionID string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if message, err := checkClock(state); err != nil {
+			return state, message, err
+		}
+		fresh, err := s.applyOptions(newState(dictionary, state.WordListID), dictionary, optionsOf(state))
+		if err != nil {
+			return state, "", err
+		}
+		if state.Timer == nil {
+			fresh.Score = state.Score
+		}
+		fresh.Hints = state.Hints
+		if state.Daily != nil {
+			fresh.Daily.Found = state.Daily.Found
+		}
+		return fresh, "Word builder has been reset.", nil
+	})
+}
+
+// AddLetter adds a letter to the answer of a session
+func (s *WordBuilderService) AddLetter(sessionID, letter, position string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if state.Ghost != nil {
+			return models.GhostPlayerMove(state, dictionary, letter, position)
+		}
+		return s.play(state, dictionary, func() (models.WordBuilderState, string, error) {
+			if state.Puzzle != nil {
+				return models.PuzzleMove(state, dictionary, letter, position)
+			}
+			return models.AddLetter(state, dictionary, letter, position)
+		})
+	})
+}
+
+// InsertLetter adds a letter inside the answer of a session, before the
+// letter at index. Ghost games and puzzles only grow at the ends.
+func (s *WordBuilderService) InsertLetter(sessionID, letter string, index int) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if mode := fixedMode(state); mode != "" {
+			return state, fmt.Sprintf("Letters can only be added at the ends in %s.", mode), errNoInsert
+		}
+		return s.play(state, dictionary, func() (models.WordBuilderState, string, error) {
+			return models.InsertLetter(state, dictionary, letter, index)
+		})
+	})
+}
+
+// Challenge claims that no word contains the answer of a ghost game
+func (s *WordBuilderService) Challenge(sessionID string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		return models.GhostChallenge(state, dictionary)
+	})
+}
+
+// Hint gives the next hint for the answer of a session, which costs the hint
+// penalty. Ghost games have no hints, as they would give the game away.
+func (s *WordBuilderService) Hint(sessionID string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if state.Ghost != nil {
+			return state, "There are no hints in a ghost game.", errNoHints
+		}
+		if message, err := checkClock(state); err != nil {
+			return state, message, err
+		}
+
+		state, message, err := models.TakeHint(state, dictionary)
+		if err != nil || s.Scorer == nil {
+			return state, message, err
+		}
+		return models.ApplyPenalty(state, s.Scorer, models.PenaltyHint), message, nil
+	})
 }
 
-// GetSession retrieves a session by ID
-func (s *WordBuilderService) GetSession(sessionID string) (*models.WordBuilderState, bool) {
-	builder, exists := s.Sessions[sessionID]
-	return builder, exists
+// RemoveLetter removes the letter at index from the answer of a session
+func (s *WordBuilderService) RemoveLetter(sessionID string, index int) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if mode := fixedMode(state); mode != "" {
+			return state, fmt.Sprintf("Letters can't be taken back in %s.", mode), errNoTakeBack
+		}
+		return s.play(state, dictionary, func() (models.WordBuilderState, string, error) {
+			return models.RemoveLetter(state, dictionary, index)
+		})
+	})
+}
+
+// UndoMove reverts the most recent move of a session
+func (s *WordBuilderService) UndoMove(sessionID string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if mode := fixedMode(state); mode != "" {
+			return state, fmt.Sprintf("Moves can't be undone in %s.", mode), errNoTakeBack
+		}
+		return s.play(state, dictionary, func() (models.WordBuilderState, string, error) {
+			return models.UndoMove(state, dictionary)
+		})
+	})
 }
 
-// ResetSession resets a specific game session
-func (s *WordBuilderService) ResetSession(sessionID string) (*models.WordBuilderState, bool) {
-	_, exists := s.Sessions[sessionID]
+// RedoMove reapplies the most recently undone move of a session
+func (s *WordBuilderService) RedoMove(sessionID string) (models.WordBuilderState, string, error) {
+	return s.update(sessionID, func(state models.WordBuilderState, dictionary *models.WordDictionary) (models.WordBuilderState, string, error) {
+		if mode := fixedMode(state); mode != "" {
+			return state, fmt.Sprintf("Moves can't be redone in %s.", mode), errNoTakeBack
+		}
+		return s.play(state, dictionary, func() (models.WordBuilderState, string, error) {
+			return models.RedoMove(state, dictionary)
+		})
+	})
+}
+
+// fixedMode names the mode of a session if its moves can't be taken back
+func fixedMode(state models.WordBuilderState) string {
+	switch {
+	case state.Ghost != nil:
+		return "a ghost game"
+	case state.Puzzle != nil:
+		return "a puzzle"
+	}
+	return ""
+}
+
+// play makes a move in a classic or timed game. Moves are refused once the
+// clock of a timed round runs out. Taking a letter off the answer costs the
+// removal penalty however it happens, and each valid word earns points the
+// first time it is made.
+func (s *WordBuilderService) play(before models.WordBuilderState, dictionary *models.WordDictionary, move func() (models.WordBuilderState, string, error)) (models.WordBuilderState, string, error) {
+	if message, err := checkClock(before); err != nil {
+		return before, message, err
+	}
+
+	state, message, err := move()
+	if err != nil {
+		return state, message, err
+	}
+	if state.Rack != nil {
+		state = models.ConstrainToRack(state)
+	}
+	state = models.RecordFoundWord(state)
+	state, found := models.RecordDailyTarget(state)
+	if found != "" {
+		message += "\n" + found
+	}
+	if s.Scorer == nil {
+		return state, message, nil
+	}
+
+	if utf8.RuneCountInString(state.Answer) < utf8.RuneCountInString(before.Answer) {
+		state = models.ApplyPenalty(state, s.Scorer, models.PenaltyRemove)
+	}
+	state, points := models.ScoreAnswer(state, dictionary, s.Scorer)
+	if points != "" {
+		message += "\n" + points
+	}
+	return state, message, nil
+}
+
+// checkClock refuses moves once the clock of a timed round runs out
+func checkClock(state models.WordBuilderState) (string, error) {
+	if state.Timer != nil && state.Timer.Expired(time.Now()) {
+		return fmt.Sprintf("Time is up! You found %d words.", len(state.Timer.Found)), ErrTimeUp
+	}
+	return "", nil
+}
+
+// UpdateDictionary sets the default dictionary for sessions started without
+// a word list. Sessions already in progress keep their own dictionary.
+func (s *WordBuilderService) UpdateDictionary(wordListID int, dictionary *models.WordDictionary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dictionary = dictionary
+	s.defaultWordListID = wordListID
+}
+
+// update applies a move to a session while holding its lock and writes the
+// result through to the database
+func (s *WordBuilderService) update(sessionID string, move func(models.WordBuilderState, *models.WordDictionary) (models.WordBuilderState, string, error)) (models.WordBuilderState, string, error) {
+	sess, exists := s.acquire(sessionID)
 	if !exists {
+		return models.WordBuilderState{}, "", ErrSessionNotFound
+	}
+	defer sess.mu.Unlock()
+
+	state, message, err := move(sess.state, sess.dictionary)
+	if err != nil {
+		return sess.state, message, err
+	}
+	state.LastActivity = time.Now()
+	sess.state = state
+	s.persist(sessionID, &state)
+	return state, message, nil
+}
+
+// acquire finds a session and locks it, finding it again if it was evicted
+// while waiting for the lock. The caller must unlock it.
+func (s *WordBuilderService) acquire(sessionID string) (*session, bool) {
+	for {
+		sess, exists := s.lookup(sessionID)
+		if !exists {
+			return nil, false
+		}
+		sess.mu.Lock()
+		switch {
+		case sess.removed:
+			sess.mu.Unlock()
+			return nil, false
+		case sess.evicted:
+			sess.mu.Unlock()
+			continue
+		}
+		return sess, true
+	}
+}
+
+// lookup finds a session in memory, loading it from the database if needed
+func (s *WordBuilderService) lookup(sessionID string) (*session, bool) {
+	s.mu.RLock()
+	sess, exists := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if exists || s.DBService == nil {
+		return sess, exists
+	}
+
+	saved, err := s.DBService.GetSession(sessionID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load session %s: %v", sessionID, err)
+		}
 		return nil, false
 	}
-	// Create a new, fresh state
-	state := models.WordBuilderState{
-		Answer:           "",
-		PrefixSet:        make(map[string]bool),
-		SuffixSet:        make(map[string]bool),
-		Step:             0,
-		IsValidWord:      false,
-		ValidCompletions: []string{},
-		Suggestion:       "",
+
+	var dictionary *models.WordDictionary
+	if saved.WordListID != 0 {
+		if dictionary, err = s.loadDictionary(saved.WordListID); err != nil {
+			log.Printf("Session %s falls back to the default dictionary: %v", sessionID, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have loaded it in the meantime
+	if sess, exists := s.sessions[sessionID]; exists {
+		return sess, true
+	}
+	if dictionary == nil {
+		// Saved sessions can't be played until a dictionary is loaded
+		if s.dictionary == nil {
+			return nil, false
+		}
+		dictionary = s.dictionary
+		saved.WordListID = s.defaultWordListID
+	}
+
+	// Expired sessions are cleaned up here rather than waiting for the reaper
+	if s.SessionTTL > 0 && !saved.LastActivity.IsZero() && time.Since(saved.LastActivity) > s.SessionTTL {
+		if err := s.DBService.DeleteSession(sessionID); err != nil {
+			log.Printf("Failed to delete expired session %s: %v", sessionID, err)
+		}
+		return nil, false
+	}
+	if s.MaxSessions > 0 && len(s.sessions) >= s.MaxSessions {
+		s.evictLeastActive()
+	}
+
+	// The dictionary may have changed since the session was saved, so the
+	// letter sets are recomputed rather than trusted
+	state := *saved
+	state.IsValidWord = models.CheckValidWord(state, dictionary)
+	state = models.UpdateSets(state, dictionary)
+	if state.Puzzle != nil {
+		state = models.ConstrainToPuzzle(state, dictionary)
 	}
+	if state.Rack != nil {
+		state = models.ConstrainToRack(state)
+	}
+	if state.Ghost != nil {
+		state = models.ConstrainToGhost(state)
+	}
+	sess = &session{state: state, dictionary: dictionary}
+	s.sessions[sessionID] = sess
+	return sess, true
+}
 
-	state = models.UpdateSets(state, s.Dictionary)
-	s.Sessions[sessionID] = &state
-	return &state, true
+// Today returns the date of today's daily puzzle. Days follow UTC, so
+// everyone switches to a new puzzle at the same moment.
+func Today() string {
+	return time.Now().UTC().Format(models.DailyDateFormat)
 }
 
-// UpdateDictionary updates the dictionary used by the service and resets all active sessions
-func (s *WordBuilderService) UpdateDictionary(dictionary *models.WordDictionary) {
-	s.Dictionary = dictionary
+// DailyPuzzle returns the puzzle of a day for a word list, or for the default
+// dictionary when wordListID is 0
+func (s *WordBuilderService) DailyPuzzle(date string, wordListID int) (models.DailyPuzzle, error) {
+	dictionary, wordListID, err := s.Dictionary(wordListID)
+	if err != nil {
+		return models.DailyPuzzle{}, fmt.Errorf("%w: %v", ErrWordListNotFound, err)
+	}
+	return s.dailyPuzzle(dictionary, date, wordListID)
+}
+
+// dailyPuzzle generates a daily puzzle or returns it from the cache. Puzzles
+// of days to come aren't out yet, so nobody gets to practice them.
+func (s *WordBuilderService) dailyPuzzle(dictionary *models.WordDictionary, date string, wordListID int) (models.DailyPuzzle, error) {
+	if _, err := time.Parse(models.DailyDateFormat, date); err == nil && date > Today() {
+		return models.DailyPuzzle{}, fmt.Errorf("the puzzle of %s isn't out yet", date)
+	}
+	key := fmt.Sprintf("%s/%d", date, wordListID)
+
+	s.dailyMu.Lock()
+	cached, exists := s.dailyPuzzles[key]
+	s.dailyMu.Unlock()
+	if exists && cached.dictionary == dictionary {
+		return cached.puzzle, nil
+	}
+
+	// Generating searches the dictionary, so it happens outside the lock. The
+	// puzzle of a day is always the same, so requests racing to generate it
+	// agree on it.
+	puzzle, err := models.GenerateDailyPuzzle(dictionary, date, wordListID)
+	if err != nil {
+		return models.DailyPuzzle{}, err
+	}
 
-	// Reset all active sessions to use the new dictionary
-	for sessionID := range s.Sessions {
-		state := models.WordBuilderState{
-			Answer:           "",
-			PrefixSet:        make(map[string]bool),
-			SuffixSet:        make(map[string]bool),
-			Step:             0,
-			IsValidWord:      false,
-			ValidCompletions: []string{},
-			Suggestion:       "",
+	s.dailyMu.Lock()
+	defer s.dailyMu.Unlock()
+	if cached, exists := s.dailyPuzzles[key]; exists && cached.dictionary == dictionary {
+		return cached.puzzle, nil
+	}
+	if len(s.dailyPuzzles) >= maxCachedDailyPuzzles {
+		s.dailyPuzzles = make(map[string]dailyPuzzle)
+	}
+	s.dailyPuzzles[key] = dailyPuzzle{puzzle: puzzle, dictionary: dictionary}
+	return puzzle, nil
+}
+
+// SolveRack lists the best words that can be built from letters with a word
+// list, or with the default dictionary when wordListID is 0
+func (s *WordBuilderService) SolveRack(wordListID int, letters string, limit int) ([]models.WordScore, error) {
+	dictionary, _, err := s.Dictionary(wordListID)
+	if err != nil {
+		return nil, err
+	}
+	tiles, err := models.ParseRack(dictionary, letters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRack, err)
+	}
+	return models.SolveRack(dictionary, tiles, s.Scorer, limit), nil
+}
+
+// SolveSessionRack lists the best words that can be built from the whole
+// rack of a session
+func (s *WordBuilderService) SolveSessionRack(sessionID string, limit int) ([]models.WordScore, error) {
+	sess, exists := s.acquire(sessionID)
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+	rack, dictionary := sess.state.Rack, sess.dictionary
+	sess.mu.Unlock()
+	if rack == nil {
+		return nil, fmt.Errorf("%w: the session has no rack", ErrInvalidRack)
+	}
+	return models.SolveRack(dictionary, rack.Tiles, s.Scorer, limit), nil
+}
+
+// Dictionary resolves a word list to its dictionary, or returns the default
+// dictionary and its word list ID when wordListID is 0
+func (s *WordBuilderService) Dictionary(wordListID int) (*models.WordDictionary, int, error) {
+	if wordListID != 0 {
+		dictionary, err := s.loadDictionary(wordListID)
+		return dictionary, wordListID, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dictionary == nil {
+		return nil, 0, fmt.Errorf("no dictionary is loaded")
+	}
+	return s.dictionary, s.defaultWordListID, nil
+}
+
+// loadDictionary resolves a word list to its dictionary through the word
+// list service, whose cache lets sessions on the same list share it
+func (s *WordBuilderService) loadDictionary(wordListID int) (*models.WordDictionary, error) {
+	if s.WordListService == nil {
+		return nil, fmt.Errorf("word lists are not available")
+	}
+	return s.WordListService.LoadWordListIntoDictionary(wordListID)
+}
+
+// remove deletes a session from memory and the database. The caller must
+// hold sess.mu, and not s.mu.
+func (s *WordBuilderService) remove(sessionID string, sess *session) {
+	sess.removed = true
+	s.mu.Lock()
+	// The session may have been evicted and loaded again as another
+	if s.sessions[sessionID] == sess {
+		delete(s.sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	if s.DBService != nil {
+		if err := s.DBService.DeleteSession(sessionID); err != nil {
+			log.Printf("Failed to delete session %s: %v", sessionID, err)
+		}
+	}
+}
+
+// evictLeastActive drops the least recently active session from memory to
+// make room for a new one. Its saved copy stays in the database, so it is
+// loaded again on its next request, and a move waiting for it finds it
+// again. Without a database the session would be lost, so nothing is evicted
+// and the reaper alone bounds the sessions. The caller must hold s.mu for
+// writing.
+func (s *WordBuilderService) evictLeastActive() {
+	if s.DBService == nil {
+		return
+	}
+
+	var oldestID string
+	var oldest time.Time
+	for sessionID, sess := range s.sessions {
+		// A session whose lock is held is in use, so it isn't the least active
+		if !sess.mu.TryLock() {
+			continue
+		}
+		lastActivity := sess.state.LastActivity
+		sess.mu.Unlock()
+		if oldestID == "" || lastActivity.Before(oldest) {
+			oldestID, oldest = sessionID, lastActivity
+		}
+	}
+	if oldestID == "" {
+		return
+	}
+
+	sess := s.sessions[oldestID]
+	if !sess.mu.TryLock() {
+		return
+	}
+	sess.evicted = true
+	delete(s.sessions, oldestID)
+	sess.mu.Unlock()
+}
+
+// persist writes a session through to the database, if one is configured
+func (s *WordBuilderService) persist(sessionID string, state *models.WordBuilderState) {
+	if s.DBService == nil {
+		return
+	}
+	if err := s.DBService.SaveSession(sessionID, state); err != nil {
+		log.Printf("Failed to persist session %s: %v", sessionID, err)
+	}
+
+	if state.Daily != nil {
+		result := &models.DailyResult{
+			SessionID:   sessionID,
+			Date:        state.Daily.Date,
+			WordListID:  state.WordListID,
+			Found:       state.Daily.Found,
+			TargetCount: len(state.Daily.Targets),
+			Score:       state.Score.Total,
+		}
+		if state.Daily.Complete() {
+			now := time.Now().UTC()
+			result.CompletedAt = &now
+		}
+		if err := s.DBService.SaveDailyResult(result); err != nil {
+			log.Printf("Failed to save daily result of session %s: %v", sessionID, err)
 		}
-		state = models.UpdateSets(state, s.Dictionary)
-		s.Sessions[sessionID] = &state
 	}
 }
@@ -2,8 +2,12 @@ package services
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -44,8 +48,13 @@ func NewWordListService(dbService *DatabaseService, dictService *DictionaryServi
 	}
 }
 
-// CreateWordList saves an uploaded word list file and metadata
-func (s *WordListService) CreateWordList(fileData []byte, name, description, source string) (*models.WordList, error) {
+// CreateWordList saves an uploaded word list file and metadata. The entries
+// are normalized with opts whenever the list is loaded.
+func (s *WordListService) CreateWordList(fileData []byte, name, description, source string, opts models.NormalizeOptions) (*models.WordList, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Generate a unique filename
 	timestamp := time.Now().UnixNano()
 	sanitizedName := strings.ReplaceAll(name, " ", "_")
@@ -59,7 +68,7 @@ func (s *WordListService) CreateWordList(fileData []byte, name, description, sou
 	}
 
 	// Count words and validate content
-	wordCount, err := s.countWordsInFile(filepath)
+	wordCount, alphabet, err := s.scanWordsInFile(filepath, opts)
 	if err != nil {
 		// Clean up file if error occurs
 		os.Remove(filepath)
@@ -68,11 +77,13 @@ func (s *WordListService) CreateWordList(fileData []byte, name, description, sou
 
 	// Create word list entry
 	wordList := &models.WordList{
-		Name:        name,
-		Description: description,
-		Source:      source,
-		FilePath:    filepath,
-		WordCount:   wordCount,
+		Name:          name,
+		Description:   description,
+		Source:        source,
+		FilePath:      filepath,
+		WordCount:     wordCount,
+		Alphabet:      alphabet,
+		Normalization: opts,
 	}
 
 	// Insert into database
@@ -87,8 +98,15 @@ func (s *WordListService) CreateWordList(fileData []byte, name, description, sou
 	return wordList, nil
 }
 
-// UpdateWordList updates a word list and optionally replaces the file
-func (s *WordListService) UpdateWordList(id int, name, description, source string, fileData []byte) (*models.WordList, error) {
+// UpdateWordList updates a word list and optionally replaces the file or its
+// normalization options. A nil opts keeps the current options.
+func (s *WordListService) UpdateWordList(id int, name, description, source string, fileData []byte, opts *models.NormalizeOptions) (*models.WordList, error) {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get existing word list
 	wordList, err := s.DBService.GetWordList(id)
 	if err != nil {
@@ -99,6 +117,9 @@ func (s *WordListService) UpdateWordList(id int, name, description, source strin
 	wordList.Name = name
 	wordList.Description = description
 	wordList.Source = source
+	if opts != nil {
+		wordList.Normalization = *opts
+	}
 
 	// If new file is provided, replace the existing one
 	if fileData != nil && len(fileData) > 0 {
@@ -109,6 +130,7 @@ func (s *WordListService) UpdateWordList(id int, name, description, source strin
 				return nil, fmt.Errorf("failed to remove old file: %w", err)
 			}
 		}
+		removeSnapshot(oldFilePath)
 
 		// Generate a new filename
 		timestamp := time.Now().UnixNano()
@@ -121,16 +143,24 @@ func (s *WordListService) UpdateWordList(id int, name, description, source strin
 			return nil, fmt.Errorf("failed to write file: %w", err)
 		}
 
-		// Update file path and word count
-		wordCount, err := s.countWordsInFile(filepath)
+		wordList.FilePath = filepath
+	}
+
+	// New options change the words even when the file stays the same
+	if len(fileData) == 0 && opts != nil {
+		removeSnapshot(wordList.FilePath)
+	}
+	if len(fileData) > 0 || opts != nil {
+		wordCount, alphabet, err := s.scanWordsInFile(wordList.FilePath, wordList.Normalization)
 		if err != nil {
 			// Clean up file if error occurs
-			os.Remove(filepath)
+			if len(fileData) > 0 {
+				os.Remove(wordList.FilePath)
+			}
 			return nil, fmt.Errorf("failed to process word list: %w", err)
 		}
-
-		wordList.FilePath = filepath
 		wordList.WordCount = wordCount
+		wordList.Alphabet = alphabet
 	}
 
 	// Update in database
@@ -158,6 +188,7 @@ func (s *WordListService) DeleteWordList(id int) error {
 			return fmt.Errorf("failed to remove word list file: %w", err)
 		}
 	}
+	removeSnapshot(wordList.FilePath)
 
 	// Delete from database
 	if err := s.DBService.DeleteWordList(id); err != nil {
@@ -180,28 +211,14 @@ func (s *WordListService) GetAllWordLists() ([]*models.WordList, error) {
 	return s.DBService.GetAllWordLists()
 }
 
-// countWordsInFile counts the number of words in a file
-func (s *WordListService) countWordsInFile(filePath string) (int, error) {
-	file, err := os.Open(filePath)
+// scanWordsInFile counts the words of a file left after normalization and
+// collects the letters they use
+func (s *WordListService) scanWordsInFile(filePath string, opts models.NormalizeOptions) (int, []string, error) {
+	words, err := s.DictionaryService.LoadNormalizedWordList(filePath, opts)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	defer file.Close()
-
-	count := 0
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		word := strings.TrimSpace(scanner.Text())
-		if word != "" {
-			count++
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return 0, err
-	}
-
-	return count, nil
+	return len(words), models.AlphabetOf(words), nil
 }
 
 // LoadWordListIntoDictionary loads a word list into a dictionary
@@ -218,14 +235,37 @@ func (s *WordListService) LoadWordListIntoDictionary(wordListID int) (*models.Wo
 		return nil, fmt.Errorf("word list not found: %w", err)
 	}
 
-	// Load the word list
-	words, err := s.DictionaryService.LoadWordList(wordList.FilePath)
+	// A snapshot loads much faster than the word list, as long as it was
+	// taken of the same file with the same options
+	key, err := s.snapshotKey(wordList)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load word list: %w", err)
 	}
+	dictionary, err := loadSnapshot(snapshotPath(wordList.FilePath), key)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Rebuilding the dictionary of word list %d: %v", wordListID, err)
+		}
+
+		// Load the word list
+		words, frequencies, err := s.DictionaryService.LoadWeightedWordList(wordList.FilePath, wordList.Normalization)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load word list: %w", err)
+		}
+
+		// Create dictionary
+		dictionary = s.DictionaryService.CreateWeightedDictionary(words, frequencies)
+		if err := saveSnapshot(snapshotPath(wordList.FilePath), key, dictionary); err != nil {
+			log.Printf("Failed to save the snapshot of word list %d: %v", wordListID, err)
+		}
+	}
 
-	// Create dictionary
-	dictionary := s.DictionaryService.CreateDictionary(words)
+	// Lists saved before alphabets were kept get theirs now
+	if wordList.Alphabet == nil {
+		if err := s.DBService.SetWordListAlphabet(wordListID, dictionary.GetAlphabet()); err != nil {
+			log.Printf("Failed to save the alphabet of word list %d: %v", wordListID, err)
+		}
+	}
 
 	// Add to cache
 	s.dictCache.Add(wordListID, dictionary)
@@ -233,40 +273,94 @@ func (s *WordListService) LoadWordListIntoDictionary(wordListID int) (*models.Wo
 	return dictionary, nil
 }
 
-// ReadWordListContent reads the content of a word list file
-func (s *WordListService) ReadWordListContent(id int, limit int) ([]string, error) {
-	// Get the word list
-	wordList, err := s.DBService.GetWordList(id)
+// snapshotPath returns where the dictionary snapshot of a word list file is
+// kept, next to the file
+func snapshotPath(filePath string) string {
+	return filePath + ".snapshot"
+}
+
+// snapshotKey identifies the words a word list's dictionary is built from:
+// the file, the options its entries are normalized with and how the
+// dictionary holds them
+func (s *WordListService) snapshotKey(wordList *models.WordList) (string, error) {
+	info, err := os.Stat(wordList.FilePath)
 	if err != nil {
-		return nil, fmt.Errorf("word list not found: %w", err)
+		return "", err
+	}
+	normalization, err := json.Marshal(wordList.Normalization)
+	if err != nil {
+		return "", err
 	}
+	return fmt.Sprintf("%s|%d|%d|%s|compact=%t", filepath.Base(wordList.FilePath), info.Size(), info.ModTime().UnixNano(),
+		normalization, s.DictionaryService.Options.Compact), nil
+}
 
-	// Open the file
-	file, err := os.Open(wordList.FilePath)
+// loadSnapshot reads a dictionary snapshot taken with key
+func loadSnapshot(path, key string) (*models.WordDictionary, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open word list file: %w", err)
+		return nil, err
 	}
 	defer file.Close()
+	return models.ReadSnapshot(bufio.NewReader(file), key)
+}
 
-	// Read words up to the limit
-	words := make([]string, 0, limit)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() && len(words) < limit {
-		word := strings.TrimSpace(scanner.Text())
-		if word != "" {
-			words = append(words, word)
-		}
+// saveSnapshot writes a dictionary snapshot, replacing the previous one only
+// once it is complete
+func saveSnapshot(path, key string, dictionary *models.WordDictionary) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "snapshot-*.tmp")
+	if err != nil {
+		return err
 	}
+	defer os.Remove(file.Name())
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read word list: %w", err)
+	w := bufio.NewWriter(file)
+	if err := models.WriteSnapshot(w, dictionary, key); err != nil {
+		file.Close()
+		return err
 	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
 
-	return words, nil
+// removeSnapshot deletes the dictionary snapshot of a word list file, if any
+func removeSnapshot(filePath string) {
+	if err := os.Remove(snapshotPath(filePath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Failed to remove the snapshot of %s: %v", filePath, err)
+	}
+}
+
+// SampleWordList returns the first words of a word list as its dictionary
+// holds them, and how common each is when the list has a frequency column
+func (s *WordListService) SampleWordList(id int, limit int) ([]string, map[string]float64, error) {
+	dictionary, err := s.LoadWordListIntoDictionary(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	words := dictionary.FirstWords(limit)
+	if !dictionary.HasFrequencies() {
+		return words, nil, nil
+	}
+	frequencies := make(map[string]float64, len(words))
+	for _, word := range words {
+		frequencies[word], _ = dictionary.WordFrequency(word)
+	}
+	return words, frequencies, nil
 }
 
 // ImportWordListFromReader imports words from a reader
-func (s *WordListService) ImportWordListFromReader(reader io.Reader, name, description, source string) (*models.WordList, error) {
+func (s *WordListService) ImportWordListFromReader(reader io.Reader, name, description, source string, opts models.NormalizeOptions) (*models.WordList, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Create a temporary file
 	tempFile, err := os.CreateTemp(s.UploadDir, "import-*.txt")
 	if err != nil {
@@ -297,7 +391,7 @@ func (s *WordListService) ImportWordListFromReader(reader io.Reader, name, descr
 	}
 
 	// Count words
-	wordCount, err := s.countWordsInFile(filepath)
+	wordCount, alphabet, err := s.scanWordsInFile(filepath, opts)
 	if err != nil {
 		os.Remove(filepath)
 		return nil, fmt.Errorf("failed to process word list: %w", err)
@@ -305,11 +399,13 @@ func (s *WordListService) ImportWordListFromReader(reader io.Reader, name, descr
 
 	// Create word list entry
 	wordList := &models.WordList{
-		Name:        name,
-		Description: description,
-		Source:      source,
-		FilePath:    filepath,
-		WordCount:   wordCount,
+		Name:          name,
+		Description:   description,
+		Source:        source,
+		FilePath:      filepath,
+		WordCount:     wordCount,
+		Alphabet:      alphabet,
+		Normalization: opts,
 	}
 
 	// Insert into database
@@ -11,9 +11,17 @@ import {
   WordDetailsDisplay,
   SuggestedCompletions
 } from '@/components/game/WordDisplay';
+import { WordBuilderState } from '@/types/wordbuilder';
 
 const ALL_LETTERS = [...'abcdefghijklmnopqrstuvwxyz'];
 
+// The text of the last hint, while the answer it was taken for still stands
+const currentHint = (state: WordBuilderState): string | undefined => {
+  const last = state.hints?.[state.hints.length - 1];
+  if (!last || last.step !== state.step || last.answer !== state.answer) return undefined;
+  return last.text;
+};
+
 const GamePage = () => {
   const {
     state,
@@ -127,7 +135,7 @@ const GamePage = () => {
               {state.valid_completions && state.valid_completions.length > 0 && (
                 <SuggestedCompletions
                   completions={state.valid_completions}
-                  suggestion={state.suggestion}
+                  hint={currentHint(state)}
                 />
               )}
             </div>
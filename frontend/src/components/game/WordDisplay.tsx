@@ -117,12 +117,12 @@ export const WordDetailsDisplay: React.FC<WordDetailsDisplayProps> = ({ word, de
 
 interface SuggestedCompletionsProps {
     completions: string[];
-    suggestion?: string;
+    hint?: string;
 }
 
 export const SuggestedCompletions: React.FC<SuggestedCompletionsProps> = ({
     completions,
-    suggestion
+    hint
 }) => {
     if (completions.length === 0) return null;
 
@@ -136,8 +136,8 @@ export const SuggestedCompletions: React.FC<SuggestedCompletionsProps> = ({
                     </span>
                 ))}
             </div>
-            {suggestion && (
-                <p className="mt-2 text-sm text-gray-600 italic">{suggestion}</p>
+            {hint && (
+                <p className="mt-2 text-sm text-gray-600 italic">{hint}</p>
             )}
         </div>
     );
@@ -5,7 +5,16 @@ export interface WordBuilderState {
     step: number;
     is_valid_word: boolean;
     valid_completions?: string[];
-    suggestion?: string;
+    hints?: Hint[];
+    next_hint_level?: number;
+}
+
+export interface Hint {
+    level: number;
+    step: number;
+    answer: string;
+    target?: string;
+    text: string;
 }
 
 export interface WordDetails {